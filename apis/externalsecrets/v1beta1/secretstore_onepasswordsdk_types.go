@@ -1,16 +1,266 @@
 package v1beta1
 
 import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 	esmeta "github.com/external-secrets/external-secrets/apis/meta/v1"
 )
 
-// OnePasswordSdkAuth contains a secretRef for the service account token.
+// OnePasswordSdkAuth contains the service account token, read either from a Secret or from a file.
+// Exactly one of ServiceAccountSecretRef and ServiceAccountTokenFile must be set, unless the
+// store goes through a 1Password Connect server, which ConnectTokenSecretRef authenticates to.
 type OnePasswordSdkAuth struct {
-	ServiceAccountSecretRef esmeta.SecretKeySelector `json:"serviceAccountSecretRef"`
+	// ServiceAccountSecretRef references the Secret holding the service account token.
+	// Its namespace must be set in a ClusterSecretStore.
+	// +optional
+	ServiceAccountSecretRef *esmeta.SecretKeySelector `json:"serviceAccountSecretRef,omitempty"`
+
+	// ServiceAccountTokenFile is the path of a file holding the service account token,
+	// such as a projected volume mounted into the controller.
+	// +optional
+	ServiceAccountTokenFile string `json:"serviceAccountTokenFile,omitempty"`
+
+	// FallbackServiceAccountSecretRefs reference Secrets holding further service account tokens,
+	// tried in order when 1Password does not accept the token above, such as while it is being
+	// rotated out. Their namespace must be set in a ClusterSecretStore.
+	// +optional
+	FallbackServiceAccountSecretRefs []esmeta.SecretKeySelector `json:"fallbackServiceAccountSecretRefs,omitempty"`
+
+	// ConnectTokenSecretRef references the Secret holding the access token of the 1Password
+	// Connect server at ConnectHost. It is used instead of a service account token.
+	// Its namespace must be set in a ClusterSecretStore.
+	// +optional
+	ConnectTokenSecretRef *esmeta.SecretKeySelector `json:"connectTokenSecretRef,omitempty"`
 }
 
+// OnePasswordSdkValidationStrategy selects how the store is validated.
+// +kubebuilder:validation:Enum=None;ListVaults;Authenticate
+type OnePasswordSdkValidationStrategy string
+
+const (
+	// OnePasswordSdkValidationNone skips validation, leaving the store in an unknown state.
+	OnePasswordSdkValidationNone OnePasswordSdkValidationStrategy = "None"
+	// OnePasswordSdkValidationListVaults lists the vaults of the service account.
+	OnePasswordSdkValidationListVaults OnePasswordSdkValidationStrategy = "ListVaults"
+	// OnePasswordSdkValidationAuthenticate signs in again with the service account token,
+	// checking 1Password can be reached and accepts it without accessing any vault.
+	OnePasswordSdkValidationAuthenticate OnePasswordSdkValidationStrategy = "Authenticate"
+)
+
 // OnePasswordSdkProvider configures a store to sync secrets using the 1Password sdk.
 type OnePasswordSdkProvider struct {
 	// Auth defines the information necessary to authenticate against OnePassword API
 	Auth *OnePasswordSdkAuth `json:"auth"`
+
+	// ConnectHost is the URL of a self-hosted 1Password Connect server to go through instead of the
+	// SDK, such as http://onepassword-connect:8080. Requires auth.connectTokenSecretRef.
+	// +optional
+	ConnectHost string `json:"connectHost,omitempty"`
+
+	// IntegrationName is reported to 1Password and shows up in its audit log.
+	// Defaults to external-secrets.
+	// +optional
+	IntegrationName string `json:"integrationName,omitempty"`
+
+	// IntegrationVersion is reported to 1Password and shows up in its audit log.
+	// Defaults to the version of external-secrets.
+	// +optional
+	IntegrationVersion string `json:"integrationVersion,omitempty"`
+
+	// Account is the sign-in address of the 1Password account, such as my-team.1password.com,
+	// whose service account tokens in auth are signed in with. Not supported with connectHost.
+	// +optional
+	Account string `json:"account,omitempty"`
+
+	// Vaults limits the vaults, by title or ID, this store may access.
+	// Every vault the service account can access is allowed when empty.
+	// +optional
+	Vaults []string `json:"vaults,omitempty"`
+
+	// DefaultVault, by title or ID, holds the items of references without the op:// scheme,
+	// written as <item>[/<section>]/<field>, or <item> for a whole item.
+	// +optional
+	DefaultVault string `json:"defaultVault,omitempty"`
+
+	// StrictNameMatching makes vault and item titles, in references and in Vaults, match only
+	// with the exact same case. By default they match ignoring case, as long as a single vault or
+	// item matches that way; a title matching exactly always wins.
+	// +optional
+	StrictNameMatching bool `json:"strictNameMatching,omitempty"`
+
+	// RequestTimeout bounds every call made by the provider to 1Password,
+	// independently of the reconcile deadline. No timeout is applied when unset or zero.
+	// +optional
+	RequestTimeout *metav1.Duration `json:"requestTimeout,omitempty"`
+
+	// RequestsPerSecond caps the calls made to 1Password through this store, shared by every
+	// ExternalSecret and PushSecret using it, to stay under the rate limits of the account.
+	// Calls are not limited when unset or zero.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	RequestsPerSecond int `json:"requestsPerSecond,omitempty"`
+
+	// CircuitBreaker fails the calls to 1Password through this store fast once enough of them in a
+	// row failed for 1Password being unavailable. Calls are always let through when unset.
+	// +optional
+	CircuitBreaker *OnePasswordSdkCircuitBreaker `json:"circuitBreaker,omitempty"`
+
+	// CacheTTL is how long the values read through this store are cached in memory for.
+	// Nothing is cached when unset or zero.
+	// +optional
+	CacheTTL *metav1.Duration `json:"cacheTTL,omitempty"`
+
+	// CacheTTLRules caches the values of the references they match for their own TTL instead of
+	// CacheTTL.
+	// +optional
+	CacheTTLRules []OnePasswordSdkCacheTTLRule `json:"cacheTTLRules,omitempty"`
+
+	// VaultCacheTTL is how long the vaults listed by a client are reused for. Defaults to 10s, zero
+	// disables the cache.
+	// +optional
+	VaultCacheTTL *metav1.Duration `json:"vaultCacheTTL,omitempty"`
+
+	// VaultCacheStaleWhileRevalidate keeps using the cached vaults once VaultCacheTTL has expired,
+	// while they are listed again in the background, rather than waiting for them to be listed.
+	// +optional
+	VaultCacheStaleWhileRevalidate bool `json:"vaultCacheStaleWhileRevalidate,omitempty"`
+
+	// ValidationStrategy selects how the store is validated. ListVaults shows up in the
+	// audit log of 1Password as vault access, Authenticate and None do not.
+	// +optional
+	// +kubebuilder:default=ListVaults
+	ValidationStrategy OnePasswordSdkValidationStrategy `json:"validationStrategy,omitempty"`
+
+	// RequireVaults makes the ListVaults validation fail when the service account cannot access any
+	// vault. When false, a service account without vaults is valid as long as it signs in.
+	// +optional
+	// +kubebuilder:default=true
+	RequireVaults *bool `json:"requireVaults,omitempty"`
+
+	// ContinueOnError makes dataFrom.find skip the vaults and items that cannot be read rather than
+	// failing, unless nothing could be read at all.
+	// +optional
+	ContinueOnError bool `json:"continueOnError,omitempty"`
+
+	// IgnoreMissing makes GetSecret return an empty value rather than fail when the item or field
+	// a reference points at does not exist, for secrets that are optional.
+	// +optional
+	IgnoreMissing bool `json:"ignoreMissing,omitempty"`
+
+	// MaxItems bounds the number of items dataFrom.find may sync, failing once more items
+	// match rather than reading them all. Every matching item is synced when unset or zero.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxItems int `json:"maxItems,omitempty"`
+
+	// GetAllSecretsConcurrency bounds the number of items dataFrom.find gets from 1Password at
+	// once, to stay within the rate limits of the account. Defaults to 4 when unset or zero.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	GetAllSecretsConcurrency int `json:"getAllSecretsConcurrency,omitempty"`
+
+	// WriteOnly makes the store only push secrets to 1Password: reading secrets through it, with
+	// an ExternalSecret, fails.
+	// +optional
+	WriteOnly bool `json:"writeOnly,omitempty"`
+
+	// DryRun makes PushSecret and the deletion of pushed secrets log the items and fields they
+	// would create, update or delete, without writing anything to 1Password.
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// RedactReferences replaces the names of vaults, items, sections and fields, in the errors
+	// and logs of the provider, with the first characters of their SHA-256 hash.
+	// +optional
+	RedactReferences bool `json:"redactReferences,omitempty"`
+
+	// IncludeMetadata makes dataFrom.extract return the metadata of the item along with its fields,
+	// under keys with the reserved prefix _metadata_, such as _metadata_version.
+	// +optional
+	IncludeMetadata bool `json:"includeMetadata,omitempty"`
+
+	// FieldDecodingStrategies decodes the fields dataFrom.extract returns with the given label
+	// with a decoding strategy of their own, instead of the decodingStrategy of dataFrom.extract.
+	// +optional
+	FieldDecodingStrategies map[string]ExternalSecretDecodingStrategy `json:"fieldDecodingStrategies,omitempty"`
+
+	// DefaultConversionStrategy converts the keys dataFrom.extract returns for the remoteRefs whose
+	// conversionStrategy is Default.
+	// +optional
+	DefaultConversionStrategy ExternalSecretConversionStrategy `json:"defaultConversionStrategy,omitempty"`
+
+	// DefaultDecodingStrategy decodes the secrets read through the store for the remoteRefs whose
+	// decodingStrategy is None.
+	// +optional
+	DefaultDecodingStrategy ExternalSecretDecodingStrategy `json:"defaultDecodingStrategy,omitempty"`
+
+	// DeletionProtectionTag protects the items tagged with it from being deleted: deleting one fails
+	// instead.
+	// +optional
+	DeletionProtectionTag string `json:"deletionProtectionTag,omitempty"`
+
+	// FieldMap returns the fields dataFrom.extract reads under the given keys, by field ID rather
+	// than by label, such as {"db-password": "password"}.
+	// +optional
+	FieldMap map[string]string `json:"fieldMap,omitempty"`
+
+	// IncludeFields lists the keys dataFrom.extract returns out of an item, leaving every other field
+	// out. Every field is returned when it is empty.
+	// +optional
+	IncludeFields []string `json:"includeFields,omitempty"`
+
+	// ExcludeFields lists the keys dataFrom.extract leaves out of an item, such as recovery_codes.
+	// +optional
+	ExcludeFields []string `json:"excludeFields,omitempty"`
+
+	// OmitEmptyFields leaves the fields of an item without a value, or with only whitespace, out
+	// of what dataFrom.extract returns.
+	// +optional
+	OmitEmptyFields bool `json:"omitEmptyFields,omitempty"`
+
+	// ManagedMarker stamps the items PushSecret creates or updates, so that they are told apart
+	// from the items managed by hand in 1Password.
+	// +optional
+	ManagedMarker *OnePasswordSdkManagedMarker `json:"managedMarker,omitempty"`
+}
+
+// OnePasswordSdkCacheTTLRule sets the cache TTL of the references matching its vault and item
+// patterns, shell globs such as prod-*.
+type OnePasswordSdkCacheTTLRule struct {
+	// Vault is the pattern of the vaults matched. Every vault is matched when empty.
+	// +optional
+	Vault string `json:"vault,omitempty"`
+
+	// Item is the pattern of the items matched. Every item is matched when empty.
+	// +optional
+	Item string `json:"item,omitempty"`
+
+	// TTL is how long the values of the references matched are cached for.
+	TTL metav1.Duration `json:"ttl"`
+}
+
+// OnePasswordSdkCircuitBreaker configures the circuit breaker of a store, which fails every call
+// for CoolDown once FailureThreshold calls in a row failed for 1Password being unavailable.
+type OnePasswordSdkCircuitBreaker struct {
+	// FailureThreshold is the number of calls in a row failing that opens the breaker.
+	// +kubebuilder:validation:Minimum=1
+	FailureThreshold int `json:"failureThreshold"`
+
+	// CoolDown is how long the breaker stays open before letting a call through to check whether
+	// 1Password recovered. Defaults to 30s.
+	// +optional
+	CoolDown *metav1.Duration `json:"coolDown,omitempty"`
+}
+
+// OnePasswordSdkManagedMarker is what PushSecret stamps the items it writes with.
+// At least one of Tag and SourceField must be set.
+type OnePasswordSdkManagedMarker struct {
+	// Tag is added to the tags of every pushed item, such as managed-by:external-secrets.
+	// +optional
+	Tag string `json:"tag,omitempty"`
+
+	// SourceField is the label of a text field set to the namespace and name of the pushed
+	// Secret, as <namespace>/<name>, such as external-secrets-source.
+	// +optional
+	SourceField string `json:"sourceField,omitempty"`
 }
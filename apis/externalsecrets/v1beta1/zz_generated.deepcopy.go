@@ -2148,7 +2148,23 @@ func (in *OnePasswordProvider) DeepCopy() *OnePasswordProvider {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OnePasswordSdkAuth) DeepCopyInto(out *OnePasswordSdkAuth) {
 	*out = *in
-	in.ServiceAccountSecretRef.DeepCopyInto(&out.ServiceAccountSecretRef)
+	if in.ServiceAccountSecretRef != nil {
+		in, out := &in.ServiceAccountSecretRef, &out.ServiceAccountSecretRef
+		*out = new(metav1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FallbackServiceAccountSecretRefs != nil {
+		in, out := &in.FallbackServiceAccountSecretRefs, &out.FallbackServiceAccountSecretRefs
+		*out = make([]metav1.SecretKeySelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ConnectTokenSecretRef != nil {
+		in, out := &in.ConnectTokenSecretRef, &out.ConnectTokenSecretRef
+		*out = new(metav1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OnePasswordSdkAuth.
@@ -2161,6 +2177,57 @@ func (in *OnePasswordSdkAuth) DeepCopy() *OnePasswordSdkAuth {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OnePasswordSdkCacheTTLRule) DeepCopyInto(out *OnePasswordSdkCacheTTLRule) {
+	*out = *in
+	out.TTL = in.TTL
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OnePasswordSdkCacheTTLRule.
+func (in *OnePasswordSdkCacheTTLRule) DeepCopy() *OnePasswordSdkCacheTTLRule {
+	if in == nil {
+		return nil
+	}
+	out := new(OnePasswordSdkCacheTTLRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OnePasswordSdkCircuitBreaker) DeepCopyInto(out *OnePasswordSdkCircuitBreaker) {
+	*out = *in
+	if in.CoolDown != nil {
+		in, out := &in.CoolDown, &out.CoolDown
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OnePasswordSdkCircuitBreaker.
+func (in *OnePasswordSdkCircuitBreaker) DeepCopy() *OnePasswordSdkCircuitBreaker {
+	if in == nil {
+		return nil
+	}
+	out := new(OnePasswordSdkCircuitBreaker)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OnePasswordSdkManagedMarker) DeepCopyInto(out *OnePasswordSdkManagedMarker) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OnePasswordSdkManagedMarker.
+func (in *OnePasswordSdkManagedMarker) DeepCopy() *OnePasswordSdkManagedMarker {
+	if in == nil {
+		return nil
+	}
+	out := new(OnePasswordSdkManagedMarker)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OnePasswordSdkProvider) DeepCopyInto(out *OnePasswordSdkProvider) {
 	*out = *in
@@ -2169,6 +2236,70 @@ func (in *OnePasswordSdkProvider) DeepCopyInto(out *OnePasswordSdkProvider) {
 		*out = new(OnePasswordSdkAuth)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Vaults != nil {
+		in, out := &in.Vaults, &out.Vaults
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RequestTimeout != nil {
+		in, out := &in.RequestTimeout, &out.RequestTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.CircuitBreaker != nil {
+		in, out := &in.CircuitBreaker, &out.CircuitBreaker
+		*out = new(OnePasswordSdkCircuitBreaker)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CacheTTL != nil {
+		in, out := &in.CacheTTL, &out.CacheTTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.CacheTTLRules != nil {
+		in, out := &in.CacheTTLRules, &out.CacheTTLRules
+		*out = make([]OnePasswordSdkCacheTTLRule, len(*in))
+		copy(*out, *in)
+	}
+	if in.VaultCacheTTL != nil {
+		in, out := &in.VaultCacheTTL, &out.VaultCacheTTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.RequireVaults != nil {
+		in, out := &in.RequireVaults, &out.RequireVaults
+		*out = new(bool)
+		**out = **in
+	}
+	if in.FieldDecodingStrategies != nil {
+		in, out := &in.FieldDecodingStrategies, &out.FieldDecodingStrategies
+		*out = make(map[string]ExternalSecretDecodingStrategy, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.FieldMap != nil {
+		in, out := &in.FieldMap, &out.FieldMap
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.IncludeFields != nil {
+		in, out := &in.IncludeFields, &out.IncludeFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludeFields != nil {
+		in, out := &in.ExcludeFields, &out.ExcludeFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ManagedMarker != nil {
+		in, out := &in.ManagedMarker, &out.ManagedMarker
+		*out = new(OnePasswordSdkManagedMarker)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OnePasswordSdkProvider.
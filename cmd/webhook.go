@@ -38,6 +38,7 @@ import (
 	esv1alpha1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1alpha1"
 	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
 	"github.com/external-secrets/external-secrets/pkg/controllers/crds"
+	"github.com/external-secrets/external-secrets/pkg/provider/onepasswordsdk"
 )
 
 const (
@@ -138,7 +139,12 @@ var webhookCmd = &cobra.Command{
 			setupLog.Error(err, "unable to start manager")
 			os.Exit(1)
 		}
-		if err = (&esv1beta1.ExternalSecret{}).SetupWebhookWithManager(mgr); err != nil {
+		// the 1Password SDK provider also rejects the remoteRef keys that are invalid op:// references
+		err = ctrl.NewWebhookManagedBy(mgr).
+			For(&esv1beta1.ExternalSecret{}).
+			WithValidator(onepasswordsdk.NewExternalSecretValidator(&esv1beta1.ExternalSecretValidator{})).
+			Complete()
+		if err != nil {
 			setupLog.Error(err, errCreateWebhook, "webhook", "ExternalSecret-v1beta1")
 			os.Exit(1)
 		}
@@ -150,6 +156,8 @@ var webhookCmd = &cobra.Command{
 			setupLog.Error(err, errCreateWebhook, "webhook", "ClusterSecretStore-v1beta1")
 			os.Exit(1)
 		}
+		// lets the 1Password SDK provider warn about token Secrets that do not exist
+		onepasswordsdk.SetValidationClient(mgr.GetAPIReader())
 		if err = (&esv1alpha1.ExternalSecret{}).SetupWebhookWithManager(mgr); err != nil {
 			setupLog.Error(err, errCreateWebhook, "webhook", "ExternalSecret-v1alpha1")
 			os.Exit(1)
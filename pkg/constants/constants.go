@@ -98,6 +98,15 @@ const (
 	CallAKEYLESSSMUpdateSecretVal       = "UpdateSecretVal"
 	CallAKEYLESSSMDeleteItem            = "DeleteItem"
 
+	ProviderOnePasswordSDK           = "1Password/SDK"
+	CallOnePasswordSDKSecretsResolve = "SecretsResolve"
+	CallOnePasswordSDKItemsCreate    = "ItemsCreate"
+	CallOnePasswordSDKItemsGet       = "ItemsGet"
+	CallOnePasswordSDKItemsPut       = "ItemsPut"
+	CallOnePasswordSDKItemsDelete    = "ItemsDelete"
+	CallOnePasswordSDKItemsListAll   = "ItemsListAll"
+	CallOnePasswordSDKVaultsListAll  = "VaultsListAll"
+
 	StatusError   = "error"
 	StatusSuccess = "success"
 
@@ -15,6 +15,8 @@ limitations under the License.
 package metrics
 
 import (
+	"time"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 
@@ -24,6 +26,7 @@ import (
 const (
 	ExternalSecretSubsystem = "externalsecret"
 	providerAPICalls        = "provider_api_calls_count"
+	providerAPICallDuration = "provider_api_call_duration_seconds"
 )
 
 var (
@@ -32,12 +35,25 @@ var (
 		Name:      providerAPICalls,
 		Help:      "Number of API calls towards the secret provider",
 	}, []string{"provider", "call", "status"})
+
+	syncCallsDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Subsystem: ExternalSecretSubsystem,
+		Name:      providerAPICallDuration,
+		Help:      "Duration of API calls towards the secret provider",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"provider", "call", "status"})
 )
 
 func ObserveAPICall(provider, call string, err error) {
 	syncCallsTotal.WithLabelValues(provider, call, deriveStatus(err)).Inc()
 }
 
+// ObserveAPICallDuration counts an API call like ObserveAPICall and also records how long it took.
+func ObserveAPICallDuration(provider, call string, err error, duration time.Duration) {
+	ObserveAPICall(provider, call, err)
+	syncCallsDuration.WithLabelValues(provider, call, deriveStatus(err)).Observe(duration.Seconds())
+}
+
 func deriveStatus(err error) string {
 	if err != nil {
 		return constants.StatusError
@@ -46,5 +62,5 @@ func deriveStatus(err error) string {
 }
 
 func init() {
-	metrics.Registry.MustRegister(syncCallsTotal)
+	metrics.Registry.MustRegister(syncCallsTotal, syncCallsDuration)
 }
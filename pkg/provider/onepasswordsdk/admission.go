@@ -0,0 +1,89 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+)
+
+const errAdmissionRef = "%s of ExternalSecret %q: %w"
+
+// ValidateReference checks that key is a 1Password secret reference, in defaultVault when it
+// has none, without reading 1Password.
+func ValidateReference(key, defaultVault string) error {
+	_, err := parseSecretReference(key, defaultVault)
+	return err
+}
+
+// NewExternalSecretValidator wraps the validator of the ExternalSecret webhook so that it also
+// rejects the remoteRef keys written as op:// references that are not valid ones.
+func NewExternalSecretValidator(validator admission.CustomValidator) admission.CustomValidator {
+	return &externalSecretValidator{validator}
+}
+
+// externalSecretValidator only checks the keys starting with op://: telling which other keys
+// are read from a 1Password SDK store would take reading the stores from the webhook.
+type externalSecretValidator struct {
+	admission.CustomValidator
+}
+
+func (v *externalSecretValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	warnings, err := v.CustomValidator.ValidateCreate(ctx, obj)
+	return warnings, errors.Join(err, validateReferences(obj))
+}
+
+func (v *externalSecretValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	warnings, err := v.CustomValidator.ValidateUpdate(ctx, oldObj, newObj)
+	return warnings, errors.Join(err, validateReferences(newObj))
+}
+
+// validateReferences checks the op:// keys of spec.data and spec.dataFrom[].extract, the latter
+// being item-level references.
+func validateReferences(obj runtime.Object) error {
+	es, ok := obj.(*esv1beta1.ExternalSecret)
+	if !ok {
+		return nil
+	}
+	var errs []error
+	for i, data := range es.Spec.Data {
+		if !isOpReference(data.RemoteRef.Key) {
+			continue
+		}
+		if err := ValidateReference(data.RemoteRef.Key, ""); err != nil {
+			errs = append(errs, fmt.Errorf(errAdmissionRef, fmt.Sprintf("spec.data[%d].remoteRef.key", i), es.Name, err))
+		}
+	}
+	for i, dataFrom := range es.Spec.DataFrom {
+		if dataFrom.Extract == nil || !isOpReference(dataFrom.Extract.Key) {
+			continue
+		}
+		if _, err := parseItemReference(dataFrom.Extract.Key, ""); err != nil {
+			errs = append(errs, fmt.Errorf(errAdmissionRef, fmt.Sprintf("spec.dataFrom[%d].extract.key", i), es.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func isOpReference(key string) bool {
+	return strings.HasPrefix(strings.TrimSpace(key), opReferencePrefix)
+}
@@ -0,0 +1,95 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+)
+
+func TestValidateReference(t *testing.T) {
+	assert.NoError(t, ValidateReference("op://my-vault/my-item/key1", ""))
+	assert.NoError(t, ValidateReference("my-item/key1", myVault))
+	assert.ErrorContains(t, ValidateReference("op://my-vault/", ""), `invalid 1Password secret reference "op://my-vault/"`)
+	assert.ErrorContains(t, ValidateReference("my-item/key1", ""), "without a vault")
+}
+
+func TestExternalSecretValidator(t *testing.T) {
+	externalSecret := func(key, extractKey string) *esv1beta1.ExternalSecret {
+		es := &esv1beta1.ExternalSecret{ObjectMeta: metav1.ObjectMeta{Name: "my-secret"}}
+		if key != "" {
+			es.Spec.Data = []esv1beta1.ExternalSecretData{{SecretKey: "key", RemoteRef: esv1beta1.ExternalSecretDataRemoteRef{Key: key}}}
+		}
+		if extractKey != "" {
+			es.Spec.DataFrom = []esv1beta1.ExternalSecretDataFromRemoteRef{{Extract: &esv1beta1.ExternalSecretDataRemoteRef{Key: extractKey}}}
+		}
+		return es
+	}
+	tests := []struct {
+		name    string
+		es      *esv1beta1.ExternalSecret
+		wantErr []string
+	}{
+		{
+			name: "valid references",
+			es:   externalSecret("op://my-vault/my-item/key1", "op://my-vault/my-item"),
+		},
+		{
+			name: "keys of other providers",
+			es:   externalSecret("path/to/secret", "path/to/item"),
+		},
+		{
+			name: "invalid field reference",
+			es:   externalSecret("op://my-vault/", ""),
+			wantErr: []string{
+				`spec.data[0].remoteRef.key of ExternalSecret "my-secret"`,
+				`invalid 1Password secret reference "op://my-vault/"`,
+			},
+		},
+		{
+			name: "field reference extracted",
+			es:   externalSecret("", "op://my-vault/my-item/key1"),
+			wantErr: []string{
+				`spec.dataFrom[0].extract.key of ExternalSecret "my-secret"`,
+				"expected an item-level reference",
+			},
+		},
+		{
+			name:    "spec checked by the wrapped validator",
+			es:      &esv1beta1.ExternalSecret{ObjectMeta: metav1.ObjectMeta{Name: "my-secret"}},
+			wantErr: []string{"either data or dataFrom should be specified"},
+		},
+	}
+	validator := NewExternalSecretValidator(&esv1beta1.ExternalSecretValidator{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, createErr := validator.ValidateCreate(context.Background(), tt.es)
+			_, updateErr := validator.ValidateUpdate(context.Background(), nil, tt.es)
+			for _, err := range []error{createErr, updateErr} {
+				if tt.wantErr == nil {
+					assert.NoError(t, err)
+				}
+				for _, want := range tt.wantErr {
+					assert.ErrorContains(t, err, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,344 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/1password/onepassword-sdk-go"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	esmeta "github.com/external-secrets/external-secrets/apis/meta/v1"
+	"github.com/external-secrets/external-secrets/pkg/utils/resolvers"
+)
+
+const (
+	errReadTokenFile  = "failed to read the 1Password service account token file: %w"
+	errClientClosed   = "1Password client is closed"
+	errInlineToken    = "spec.provider.onepasswordsdk.auth.%s holds a service account token, store it in a Secret and reference it instead"
+	warnInlineToken   = "spec.provider.onepasswordsdk.auth.%s looks like part of a service account token, it should name where the token is stored instead"
+	errMalformedToken = "1Password service account token appears malformed: it does not start with %s, check the referenced Secret key or token file holds the token itself"
+	warnSecretMissing = "spec.provider.onepasswordsdk.auth.%s references Secret %q in namespace %q, which does not exist yet: the store is not ready until it is created"
+	warnKeyMissing    = "spec.provider.onepasswordsdk.auth.%s references key %q of Secret %q in namespace %q, which it does not have"
+	errTokenAccount   = "1Password service account token is for account %q, not spec.provider.onepasswordsdk.account %q"
+	errNoTokenAccount = "cannot tell the account of the 1Password service account token, which spec.provider.onepasswordsdk.account requires: %w"
+
+	// secretCheckTimeout bounds looking up the Secrets of the auth spec on admission.
+	secretCheckTimeout = 5 * time.Second
+	// reconnectDelay is waited before rebuilding a client whose connection dropped, to let
+	// whatever dropped it settle.
+	reconnectDelay = 200 * time.Millisecond
+
+	// serviceAccountTokenPrefix starts every 1Password service account token. The rest is base64
+	// encoded JSON, which starts with base64JSONPrefix.
+	serviceAccountTokenPrefix = "ops_"
+	base64JSONPrefix          = "eyJ"
+)
+
+// authErrors are matched against the error messages of the SDK, like in mapError.
+var authErrors = []string{
+	"unauthorized",
+	"unauthenticated",
+	"invalid service account token",
+	"token has expired",
+	"token was revoked",
+}
+
+// authField is a string of the auth spec, along with its path under spec.provider.onepasswordsdk.auth.
+type authField struct {
+	path  string
+	value string
+}
+
+// authFields returns the strings of auth naming where the service account tokens are stored,
+// which would hold a token pasted in by mistake.
+func authFields(auth *esv1beta1.OnePasswordSdkAuth) []authField {
+	var fields []authField
+	if ref := auth.ServiceAccountSecretRef; ref != nil {
+		fields = append(fields,
+			authField{path: "serviceAccountSecretRef.name", value: ref.Name},
+			authField{path: "serviceAccountSecretRef.key", value: ref.Key},
+		)
+	}
+	if auth.ServiceAccountTokenFile != "" {
+		fields = append(fields, authField{path: "serviceAccountTokenFile", value: auth.ServiceAccountTokenFile})
+	}
+	for i, ref := range auth.FallbackServiceAccountSecretRefs {
+		fields = append(fields,
+			authField{path: fmt.Sprintf("fallbackServiceAccountSecretRefs[%d].name", i), value: ref.Name},
+			authField{path: fmt.Sprintf("fallbackServiceAccountSecretRefs[%d].key", i), value: ref.Key},
+		)
+	}
+	if ref := auth.ConnectTokenSecretRef; ref != nil {
+		fields = append(fields,
+			authField{path: "connectTokenSecretRef.name", value: ref.Name},
+			authField{path: "connectTokenSecretRef.key", value: ref.Key},
+		)
+	}
+	return fields
+}
+
+// checkInlineTokens rejects a service account token put in place of where it is stored, and warns
+// about what looks like a token stripped of its prefix.
+func checkInlineTokens(auth *esv1beta1.OnePasswordSdkAuth) ([]string, error) {
+	var warnings []string
+	for _, field := range authFields(auth) {
+		value := strings.TrimSpace(field.value)
+		if strings.HasPrefix(value, serviceAccountTokenPrefix) {
+			return nil, fmt.Errorf(errInlineToken, field.path)
+		}
+		if strings.HasPrefix(value, base64JSONPrefix) {
+			warnings = append(warnings, fmt.Sprintf(warnInlineToken, field.path))
+		}
+	}
+	return warnings, nil
+}
+
+// validationClient reads the Secrets of the auth spec on admission, when set with
+// SetValidationClient.
+var validationClient client.Reader
+
+// SetValidationClient makes ValidateStore warn about the Secrets of the auth spec that do not
+// exist or lack the referenced key, read with reader. The webhook sets it, but only checks the
+// Secrets once its service account is granted to get them: until then the check is skipped.
+func SetValidationClient(reader client.Reader) {
+	validationClient = reader
+}
+
+// authSecretRef is a reference of the auth spec to a Secret, along with its path under
+// spec.provider.onepasswordsdk.auth.
+type authSecretRef struct {
+	path string
+	ref  esmeta.SecretKeySelector
+}
+
+// authSecretRefs returns the references of auth to the Secrets holding tokens.
+func authSecretRefs(auth *esv1beta1.OnePasswordSdkAuth) []authSecretRef {
+	var refs []authSecretRef
+	if auth.ServiceAccountSecretRef != nil {
+		refs = append(refs, authSecretRef{path: "serviceAccountSecretRef", ref: *auth.ServiceAccountSecretRef})
+	}
+	for i, ref := range auth.FallbackServiceAccountSecretRefs {
+		refs = append(refs, authSecretRef{path: fmt.Sprintf("fallbackServiceAccountSecretRefs[%d]", i), ref: ref})
+	}
+	if auth.ConnectTokenSecretRef != nil {
+		refs = append(refs, authSecretRef{path: "connectTokenSecretRef", ref: *auth.ConnectTokenSecretRef})
+	}
+	return refs
+}
+
+// checkTokenSecrets returns a warning for every Secret of the auth spec that does not exist or
+// lacks the referenced key. The store is not rejected, as the Secret may well be created after
+// it. Any other error reading a Secret, such as the reader lacking permission, skips it.
+func checkTokenSecrets(ctx context.Context, reader client.Reader, store esv1beta1.GenericStore) []string {
+	var warnings []string
+	for _, secretRef := range authSecretRefs(store.GetSpec().Provider.OnePasswordSdk.Auth) {
+		namespace := store.GetNamespace()
+		if secretRef.ref.Namespace != nil {
+			namespace = *secretRef.ref.Namespace
+		}
+		var secret corev1.Secret
+		err := reader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: secretRef.ref.Name}, &secret)
+		switch {
+		case apierrors.IsNotFound(err):
+			warnings = append(warnings, fmt.Sprintf(warnSecretMissing, secretRef.path, secretRef.ref.Name, namespace))
+		case err != nil:
+			continue
+		default:
+			if _, ok := secret.Data[secretRef.ref.Key]; !ok {
+				warnings = append(warnings, fmt.Sprintf(warnKeyMissing, secretRef.path, secretRef.ref.Key, secretRef.ref.Name, namespace))
+			}
+		}
+	}
+	return warnings
+}
+
+// connectFunc builds a client signed in with the current service account token.
+type connectFunc func(ctx context.Context) (*onepassword.Client, error)
+
+// clientFactory builds the clients of the SDK. The APIs of a client are the SecretsAPI, ItemsAPI
+// and VaultsAPI interfaces of the SDK, which the fake package implements: tests replace the
+// factory to go through the whole of NewClient without signing in to 1Password.
+var clientFactory = onepassword.NewClient
+
+// signInFunc builds a client signed in with token.
+type signInFunc func(ctx context.Context, token string) (*onepassword.Client, error)
+
+// newConnectFunc returns a connectFunc signing in with the first service account token of auth
+// accepted by 1Password, trying the fallback tokens in order. When account is set, the tokens of
+// other accounts are skipped.
+func newConnectFunc(auth *esv1beta1.OnePasswordSdkAuth, account string, kube client.Client, storeKind, namespace string, signIn signInFunc) connectFunc {
+	return func(ctx context.Context) (*onepassword.Client, error) {
+		tokens := make([]func() (string, error), 0, 1+len(auth.FallbackServiceAccountSecretRefs))
+		tokens = append(tokens, func() (string, error) {
+			return resolveToken(ctx, auth, kube, storeKind, namespace)
+		})
+		for i := range auth.FallbackServiceAccountSecretRefs {
+			ref := &auth.FallbackServiceAccountSecretRefs[i]
+			tokens = append(tokens, func() (string, error) {
+				return resolvers.SecretKeyRef(ctx, kube, storeKind, namespace, ref)
+			})
+		}
+
+		var errs []error
+		for _, token := range tokens {
+			serviceAccountToken, err := token()
+			if err == nil {
+				serviceAccountToken, err = checkTokenFormat(ctx, serviceAccountToken)
+			}
+			if err == nil && account != "" {
+				err = checkTokenAccount(serviceAccountToken, account)
+			}
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			sdkClient, err := signIn(ctx, serviceAccountToken)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			return sdkClient, nil
+		}
+		return nil, errors.Join(errs...)
+	}
+}
+
+// checkTokenFormat fails on a token that cannot be a service account token, so that the wrong
+// value does not surface as a confusing error of the SDK. It only trims surrounding whitespace,
+// such as the newline of a Secret created from a file, and logs a token whose body does not
+// look like base64 encoded JSON, as that could change in later token formats.
+func checkTokenFormat(ctx context.Context, token string) (string, error) {
+	token = strings.TrimSpace(token)
+	body, ok := strings.CutPrefix(token, serviceAccountTokenPrefix)
+	if !ok {
+		return "", fmt.Errorf(errMalformedToken, serviceAccountTokenPrefix)
+	}
+	if !strings.HasPrefix(body, base64JSONPrefix) {
+		loggerFrom(ctx).Info("1Password service account token has an unexpected format, signing in anyway")
+	}
+	return token, nil
+}
+
+// checkTokenAccount fails unless token signs in to account, read from the sign-in address encoded
+// in the token, as the SDK cannot be told which account to sign in to.
+func checkTokenAccount(token, account string) error {
+	body := strings.TrimRight(strings.TrimPrefix(token, serviceAccountTokenPrefix), "=")
+	decoded, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil {
+		decoded, err = base64.RawStdEncoding.DecodeString(body)
+	}
+	if err != nil {
+		return fmt.Errorf(errNoTokenAccount, errors.New("the token is not base64 encoded"))
+	}
+	var claims struct {
+		SignInAddress string `json:"signInAddress"`
+	}
+	if err := json.Unmarshal(decoded, &claims); err != nil || claims.SignInAddress == "" {
+		return fmt.Errorf(errNoTokenAccount, errors.New("the token has no sign-in address"))
+	}
+	if tokenAccount := normalizeAccount(claims.SignInAddress); tokenAccount != normalizeAccount(account) {
+		return fmt.Errorf(errTokenAccount, tokenAccount, account)
+	}
+	return nil
+}
+
+// normalizeAccount returns the sign-in address account, lowercased and without scheme or
+// trailing slash, so that https://My-Team.1password.com/ matches my-team.1password.com.
+func normalizeAccount(account string) string {
+	account = strings.ToLower(strings.TrimSpace(account))
+	account = strings.TrimPrefix(account, "https://")
+	return strings.TrimSuffix(account, "/")
+}
+
+// validAccount reports whether account is a sign-in address, such as my-team.1password.com.
+func validAccount(account string) bool {
+	account = normalizeAccount(account)
+	return strings.Contains(account, ".") && len(validation.IsDNS1123Subdomain(account)) == 0
+}
+
+// resolveToken returns the service account token, from the referenced Secret or the token file.
+func resolveToken(ctx context.Context, auth *esv1beta1.OnePasswordSdkAuth, kube client.Client, storeKind, namespace string) (string, error) {
+	if auth.ServiceAccountSecretRef != nil {
+		return resolvers.SecretKeyRef(ctx, kube, storeKind, namespace, auth.ServiceAccountSecretRef)
+	}
+	token, err := os.ReadFile(auth.ServiceAccountTokenFile)
+	if err != nil {
+		return "", fmt.Errorf(errReadTokenFile, err)
+	}
+	return strings.TrimSpace(string(token)), nil
+}
+
+// reauth calls fn, and when it fails to authenticate, signs in again with the token read anew
+// and calls fn a second time. A rotated token is thereby picked up by the call that first runs
+// into the old one being rejected. When the connection to 1Password dropped instead, the client
+// is rebuilt after reconnectDelay. The client is rebuilt at most once per call.
+func reauth[T any](ctx context.Context, provider *ProviderOnePasswordSdk, fn func() (T, error)) (T, error) {
+	if provider.closed {
+		var zero T
+		return zero, errors.New(errClientClosed)
+	}
+	value, err := fn()
+	if err == nil || provider.connect == nil {
+		return value, err
+	}
+	switch {
+	case isAuthError(err):
+	case isConnectionError(err):
+		loggerFrom(ctx).V(1).Info("reconnecting to 1Password", "error", provider.redact.message(err.Error()))
+		select {
+		case <-time.After(reconnectDelay):
+		case <-ctx.Done():
+			return value, err
+		}
+	default:
+		return value, err
+	}
+	sdkClient, connectErr := provider.connect(ctx)
+	if connectErr != nil {
+		// the token is most likely still the one rejected, report the original failure
+		return value, err
+	}
+	provider.useClient(sdkClient)
+	return fn()
+}
+
+// tokenRejected types err, about 1Password rejecting the token, as ErrTokenRevoked.
+func tokenRejected(err error) error {
+	return newTypedError(ErrTokenRevoked, fmt.Errorf(errTokenRejected, err))
+}
+
+// isAuthError reports whether err is 1Password rejecting the service account token.
+func isAuthError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, auth := range authErrors {
+		if strings.Contains(msg, auth) {
+			return true
+		}
+	}
+	return false
+}
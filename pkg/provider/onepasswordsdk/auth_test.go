@@ -0,0 +1,424 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/1password/onepassword-sdk-go"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	esmeta "github.com/external-secrets/external-secrets/apis/meta/v1"
+	"github.com/external-secrets/external-secrets/pkg/provider/onepasswordsdk/fake"
+)
+
+func TestResolveTokenFile(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	assert.NoError(t, os.WriteFile(tokenFile, []byte("ops_token\n"), 0o600))
+
+	token, err := resolveToken(context.Background(), &esv1beta1.OnePasswordSdkAuth{ServiceAccountTokenFile: tokenFile}, nil, esv1beta1.SecretStoreKind, "default")
+	assert.NoError(t, err)
+	assert.Equal(t, "ops_token", token)
+
+	_, err = resolveToken(context.Background(), &esv1beta1.OnePasswordSdkAuth{ServiceAccountTokenFile: tokenFile + ".missing"}, nil, esv1beta1.SecretStoreKind, "default")
+	assert.ErrorContains(t, err, "failed to read the 1Password service account token file")
+}
+
+func TestConnectFallback(t *testing.T) {
+	kube := clientfake.NewClientBuilder().WithObjects(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "token-old", Namespace: "default"},
+			Data:       map[string][]byte{"token": []byte("ops_old")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "token-new", Namespace: "default"},
+			Data:       map[string][]byte{"token": []byte("ops_new")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "token-wrong", Namespace: "default"},
+			Data:       map[string][]byte{"token": []byte("hunter2")},
+		},
+	).Build()
+	tests := []struct {
+		name        string
+		auth        *esv1beta1.OnePasswordSdkAuth
+		wantSignIns []string
+		wantErr     string
+	}{
+		{
+			name: "first accepted token is used",
+			auth: &esv1beta1.OnePasswordSdkAuth{
+				ServiceAccountSecretRef:          &esmeta.SecretKeySelector{Name: "token-new", Key: "token"},
+				FallbackServiceAccountSecretRefs: []esmeta.SecretKeySelector{{Name: "token-old", Key: "token"}},
+			},
+			wantSignIns: []string{"ops_new"},
+		},
+		{
+			name: "rejected token falls back",
+			auth: &esv1beta1.OnePasswordSdkAuth{
+				ServiceAccountSecretRef:          &esmeta.SecretKeySelector{Name: "token-old", Key: "token"},
+				FallbackServiceAccountSecretRefs: []esmeta.SecretKeySelector{{Name: "token-new", Key: "token"}},
+			},
+			wantSignIns: []string{"ops_old", "ops_new"},
+		},
+		{
+			name: "missing secret falls back",
+			auth: &esv1beta1.OnePasswordSdkAuth{
+				ServiceAccountSecretRef:          &esmeta.SecretKeySelector{Name: "token-missing", Key: "token"},
+				FallbackServiceAccountSecretRefs: []esmeta.SecretKeySelector{{Name: "token-new", Key: "token"}},
+			},
+			wantSignIns: []string{"ops_new"},
+		},
+		{
+			name: "malformed token falls back without signing in",
+			auth: &esv1beta1.OnePasswordSdkAuth{
+				ServiceAccountSecretRef:          &esmeta.SecretKeySelector{Name: "token-wrong", Key: "token"},
+				FallbackServiceAccountSecretRefs: []esmeta.SecretKeySelector{{Name: "token-new", Key: "token"}},
+			},
+			wantSignIns: []string{"ops_new"},
+		},
+		{
+			name: "every token is rejected",
+			auth: &esv1beta1.OnePasswordSdkAuth{
+				ServiceAccountSecretRef:          &esmeta.SecretKeySelector{Name: "token-old", Key: "token"},
+				FallbackServiceAccountSecretRefs: []esmeta.SecretKeySelector{{Name: "token-missing", Key: "token"}},
+			},
+			wantSignIns: []string{"ops_old"},
+			wantErr:     "token was revoked",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var signIns []string
+			connect := newConnectFunc(tt.auth, "", kube, esv1beta1.SecretStoreKind, "default", func(_ context.Context, token string) (*onepassword.Client, error) {
+				signIns = append(signIns, token)
+				if token == "ops_old" {
+					return nil, errors.New("error initializing client: Unauthorized: the service account token was revoked")
+				}
+				return &onepassword.Client{}, nil
+			})
+			got, err := connect(context.Background())
+			assert.Equal(t, tt.wantSignIns, signIns)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.ErrorContains(t, err, `"token-missing" not found`)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, got)
+		})
+	}
+}
+
+func TestConnectAccount(t *testing.T) {
+	token := func(signInAddress string) string {
+		return serviceAccountTokenPrefix + base64.RawURLEncoding.EncodeToString([]byte(`{"signInAddress":"`+signInAddress+`"}`))
+	}
+	tokenA, tokenB := token("team-a.1password.com"), token("https://Team-B.1password.eu/")
+	kube := clientfake.NewClientBuilder().WithObjects(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "token-a", Namespace: "default"},
+			Data:       map[string][]byte{"token": []byte(tokenA)},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "token-b", Namespace: "default"},
+			Data:       map[string][]byte{"token": []byte(tokenB)},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "token-opaque", Namespace: "default"},
+			Data:       map[string][]byte{"token": []byte("ops_opaque")},
+		},
+	).Build()
+	auth := &esv1beta1.OnePasswordSdkAuth{
+		ServiceAccountSecretRef:          &esmeta.SecretKeySelector{Name: "token-a", Key: "token"},
+		FallbackServiceAccountSecretRefs: []esmeta.SecretKeySelector{{Name: "token-opaque", Key: "token"}, {Name: "token-b", Key: "token"}},
+	}
+	tests := []struct {
+		name        string
+		account     string
+		wantSignIns []string
+		wantErr     []string
+	}{
+		{
+			name:        "first token is used without an account",
+			wantSignIns: []string{tokenA},
+		},
+		{
+			name:        "tokens of other accounts are skipped",
+			account:     "team-b.1password.eu",
+			wantSignIns: []string{tokenB},
+		},
+		{
+			name:    "no token of the account",
+			account: "team-c.1password.com",
+			wantErr: []string{
+				`token is for account "team-a.1password.com", not spec.provider.onepasswordsdk.account "team-c.1password.com"`,
+				"cannot tell the account of the 1Password service account token",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var signIns []string
+			connect := newConnectFunc(auth, tt.account, kube, esv1beta1.SecretStoreKind, "default", func(_ context.Context, token string) (*onepassword.Client, error) {
+				signIns = append(signIns, token)
+				return &onepassword.Client{}, nil
+			})
+			_, err := connect(context.Background())
+			assert.Equal(t, tt.wantSignIns, signIns)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			}
+			for _, want := range tt.wantErr {
+				assert.ErrorContains(t, err, want)
+			}
+		})
+	}
+}
+
+func TestClientFactory(t *testing.T) {
+	// the core is checked first, with the real factory, so that it is not checked with the fake
+	assert.NoError(t, checkSDKCore(context.Background()))
+	kube := clientfake.NewClientBuilder().WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "token", Namespace: "default"},
+		Data:       map[string][]byte{"token": []byte("ops_token")},
+	}).Build()
+	store := &esv1beta1.SecretStore{
+		ObjectMeta: metav1.ObjectMeta{Name: "store", Namespace: "default"},
+		Spec: esv1beta1.SecretStoreSpec{
+			Provider: &esv1beta1.SecretStoreProvider{OnePasswordSdk: &esv1beta1.OnePasswordSdkProvider{
+				Auth: &esv1beta1.OnePasswordSdkAuth{ServiceAccountSecretRef: &esmeta.SecretKeySelector{Name: "token", Key: "token"}},
+			}},
+		},
+	}
+
+	var signIns int
+	defer func(factory func(context.Context, ...onepassword.ClientOption) (*onepassword.Client, error)) {
+		clientFactory = factory
+	}(clientFactory)
+	clientFactory = func(context.Context, ...onepassword.ClientOption) (*onepassword.Client, error) {
+		signIns++
+		client := newFakeClient().SDKClient()
+		return &client, nil
+	}
+
+	secretsClient, err := (&ProviderOnePasswordSdk{}).NewClient(context.Background(), store, kube, "default")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, signIns)
+	got, err := secretsClient.GetSecret(context.Background(), esv1beta1.ExternalSecretDataRemoteRef{Key: "op://my-vault/my-item/key1"})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(value1), got)
+	assert.NoError(t, secretsClient.Close(context.Background()))
+
+	clientFactory = func(context.Context, ...onepassword.ClientOption) (*onepassword.Client, error) {
+		return nil, errors.New("error initializing client: Unauthorized: the service account token was revoked")
+	}
+	_, err = (&ProviderOnePasswordSdk{}).NewClient(context.Background(), store, kube, "default")
+	assert.ErrorContains(t, err, "token was revoked")
+}
+
+func TestCheckTokenFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "service account token",
+			token: "ops_eyJzaWduSW5BZGRyZXNzIjoibXkuMXBhc3N3b3JkLmNvbSJ9",
+			want:  "ops_eyJzaWduSW5BZGRyZXNzIjoibXkuMXBhc3N3b3JkLmNvbSJ9",
+		},
+		{
+			name:  "surrounding whitespace is trimmed",
+			token: " ops_eyJ\n",
+			want:  "ops_eyJ",
+		},
+		{
+			name:  "unexpected body is let through",
+			token: "ops_v2.abc",
+			want:  "ops_v2.abc",
+		},
+		{
+			name:    "missing prefix",
+			token:   "eyJzaWduSW5BZGRyZXNzIjoibXkuMXBhc3N3b3JkLmNvbSJ9",
+			wantErr: "token appears malformed: it does not start with ops_",
+		},
+		{
+			name:    "other secret",
+			token:   "hunter2",
+			wantErr: "token appears malformed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := checkTokenFormat(context.Background(), tt.token)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.NotContains(t, err.Error(), tt.token)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCheckTokenSecrets(t *testing.T) {
+	kube := clientfake.NewClientBuilder().WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "token", Namespace: "default"},
+		Data:       map[string][]byte{"token": []byte("ops_token")},
+	}).Build()
+	newStore := func(auth *esv1beta1.OnePasswordSdkAuth) *esv1beta1.SecretStore {
+		return &esv1beta1.SecretStore{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default"},
+			Spec: esv1beta1.SecretStoreSpec{
+				Provider: &esv1beta1.SecretStoreProvider{OnePasswordSdk: &esv1beta1.OnePasswordSdkProvider{Auth: auth}},
+			},
+		}
+	}
+	tests := []struct {
+		name string
+		auth *esv1beta1.OnePasswordSdkAuth
+		want []string
+	}{
+		{
+			name: "existing secret and key",
+			auth: &esv1beta1.OnePasswordSdkAuth{ServiceAccountSecretRef: &esmeta.SecretKeySelector{Name: "token", Key: "token"}},
+		},
+		{
+			name: "missing secret",
+			auth: &esv1beta1.OnePasswordSdkAuth{ServiceAccountSecretRef: &esmeta.SecretKeySelector{Name: "tokn", Key: "token"}},
+			want: []string{`spec.provider.onepasswordsdk.auth.serviceAccountSecretRef references Secret "tokn" in namespace "default", which does not exist yet: the store is not ready until it is created`},
+		},
+		{
+			name: "missing key of a fallback",
+			auth: &esv1beta1.OnePasswordSdkAuth{
+				ServiceAccountSecretRef:          &esmeta.SecretKeySelector{Name: "token", Key: "token"},
+				FallbackServiceAccountSecretRefs: []esmeta.SecretKeySelector{{Name: "token", Key: "old-token"}},
+			},
+			want: []string{`spec.provider.onepasswordsdk.auth.fallbackServiceAccountSecretRefs[0] references key "old-token" of Secret "token" in namespace "default", which it does not have`},
+		},
+		{
+			name: "token file",
+			auth: &esv1beta1.OnePasswordSdkAuth{ServiceAccountTokenFile: "/var/run/secrets/1password/token"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, checkTokenSecrets(context.Background(), kube, newStore(tt.auth)))
+		})
+	}
+
+	// the store is admitted with the warnings
+	SetValidationClient(kube)
+	defer SetValidationClient(nil)
+	warnings, err := (&ProviderOnePasswordSdk{}).ValidateStore(newStore(tests[1].auth))
+	assert.NoError(t, err)
+	assert.Len(t, warnings, 1)
+}
+
+func TestReauth(t *testing.T) {
+	errUnauthorized := errors.New("Unauthorized: the service account token was revoked")
+	tests := []struct {
+		name         string
+		client       *fake.Client
+		connected    *fake.Client
+		connectErr   error
+		wantConnects int
+		wantErr      string
+	}{
+		{
+			name:         "rotated token is picked up",
+			client:       newFakeClient().WithError(fake.SecretsResolve, errUnauthorized),
+			connected:    newFakeClient(),
+			wantConnects: 1,
+		},
+		{
+			name:         "client is rebuilt only once",
+			client:       newFakeClient().WithError(fake.SecretsResolve, errUnauthorized),
+			connected:    newFakeClient().WithError(fake.SecretsResolve, errUnauthorized),
+			wantConnects: 1,
+			wantErr:      "token was revoked",
+		},
+		{
+			name:         "failing to sign in again reports the original error",
+			client:       newFakeClient().WithError(fake.SecretsResolve, errUnauthorized),
+			connectErr:   errors.New("secret \"token\" not found"),
+			wantConnects: 1,
+			wantErr:      "token was revoked",
+		},
+		{
+			name:         "dropped connection is rebuilt",
+			client:       newFakeClient().WithError(fake.SecretsResolve, errors.New("read tcp 10.0.0.1:443: connection reset by peer")),
+			connected:    newFakeClient(),
+			wantConnects: 1,
+		},
+		{
+			name:         "connection is rebuilt only once",
+			client:       newFakeClient().WithError(fake.SecretsResolve, errors.New("write: broken pipe")),
+			connected:    newFakeClient().WithError(fake.SecretsResolve, errors.New("write: broken pipe")),
+			wantConnects: 1,
+			wantErr:      "broken pipe",
+		},
+		{
+			name:    "not found does not reconnect",
+			client:  newFakeClient().WithError(fake.SecretsResolve, fake.ErrNotFound).WithError(fake.ItemsListAll, fake.ErrNotFound),
+			wantErr: fake.ErrNotFound.Error(),
+		},
+		{
+			name:    "other errors do not sign in again",
+			client:  newFakeClient().WithError(fake.SecretsResolve, errors.New("internal server error")),
+			wantErr: "internal server error",
+		},
+		{
+			name:   "success does not sign in again",
+			client: newFakeClient(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var connects int
+			provider := &ProviderOnePasswordSdk{
+				client: tt.client.SDKClient(),
+				connect: func(context.Context) (*onepassword.Client, error) {
+					connects++
+					if tt.connectErr != nil {
+						return nil, tt.connectErr
+					}
+					sdkClient := tt.connected.SDKClient()
+					return &sdkClient, nil
+				},
+			}
+			got, err := provider.GetSecret(context.Background(), esv1beta1.ExternalSecretDataRemoteRef{Key: "op://my-vault/my-item/key1"})
+			assert.Equal(t, tt.wantConnects, connects)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, []byte(value1), got)
+		})
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+)
+
+// getSecretsConcurrency bounds the number of references GetSecrets resolves at once.
+const getSecretsConcurrency = 8
+
+// SecretResult is the value of a reference resolved by GetSecrets, or the error resolving it.
+type SecretResult struct {
+	Value []byte
+	Err   error
+}
+
+// GetSecrets resolves every reference like GetSecret does, a few at a time, rather than one
+// after the other. It is not part of esv1beta1.SecretsClient: callers type-assert to
+// ProviderOnePasswordSdk to use it. Each reference gets its own result, so that one failing
+// reference does not fail the others. When 1Password rejects the token, the client signs in
+// again once for the whole batch.
+func (provider *ProviderOnePasswordSdk) GetSecrets(ctx context.Context, refs []esv1beta1.ExternalSecretDataRemoteRef) map[esv1beta1.ExternalSecretDataRemoteRef]SecretResult {
+	ctx = withOperation(ctx, "GetSecrets")
+	results := make(map[esv1beta1.ExternalSecretDataRemoteRef]SecretResult, len(refs))
+	failAll := provider.checkReadable()
+	if provider.closed {
+		failAll = errors.New(errClientClosed)
+	}
+	if failAll != nil {
+		for _, ref := range refs {
+			results[ref] = SecretResult{Err: failAll}
+		}
+		return results
+	}
+	var mu sync.Mutex
+
+	// resolve resolves the references without a value yet, reporting an authentication or
+	// connection error to reauth so that those are resolved again once connected again
+	resolve := func() (struct{}, error) {
+		var (
+			wg      sync.WaitGroup
+			sem     = make(chan struct{}, getSecretsConcurrency)
+			authErr error
+		)
+		for _, ref := range refs {
+			mu.Lock()
+			result, done := results[ref]
+			mu.Unlock()
+			if done && result.Err == nil {
+				continue
+			}
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(ref esv1beta1.ExternalSecretDataRemoteRef) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				value, err := provider.resolveSecret(ctx, ref)
+				mu.Lock()
+				defer mu.Unlock()
+				results[ref] = SecretResult{Value: value, Err: err}
+				if err != nil && (isAuthError(err) || isConnectionError(err)) {
+					authErr = err
+				}
+			}(ref)
+		}
+		wg.Wait()
+		return struct{}{}, authErr
+	}
+
+	// the errors are those of the references, already in results
+	_, _ = reauth(ctx, provider, resolve)
+	for ref, result := range results {
+		if result.Err != nil {
+			value, err := provider.missingSecret(ctx, mapError(result.Err))
+			results[ref] = SecretResult{Value: value, Err: err}
+		}
+	}
+	return results
+}
+
+// resolveSecret is GetSecret without signing in again, which GetSecrets does for the whole batch.
+func (provider *ProviderOnePasswordSdk) resolveSecret(ctx context.Context, ref esv1beta1.ExternalSecretDataRemoteRef) ([]byte, error) {
+	if value, ok := provider.cache.getSecret(ref); ok {
+		return value, nil
+	}
+	ctx, cancel := provider.withTimeout(ctx)
+	defer cancel()
+	value, err := retry(ctx, provider.retrier, func() ([]byte, error) {
+		return provider.getSecret(ctx, ref)
+	})
+	if err != nil {
+		return nil, err
+	}
+	provider.cache.addSecret(ref, value)
+	return value, nil
+}
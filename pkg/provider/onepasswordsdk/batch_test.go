@@ -0,0 +1,77 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/1password/onepassword-sdk-go"
+	"github.com/stretchr/testify/assert"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	"github.com/external-secrets/external-secrets/pkg/provider/onepasswordsdk/fake"
+)
+
+func TestGetSecrets(t *testing.T) {
+	ref1 := esv1beta1.ExternalSecretDataRemoteRef{Key: "op://my-vault/my-item/key1"}
+	ref2 := esv1beta1.ExternalSecretDataRemoteRef{Key: "op://my-vault/my-item/key2"}
+	missing := esv1beta1.ExternalSecretDataRemoteRef{Key: "op://my-vault/my-item/missing"}
+	errUnauthorized := errors.New("Unauthorized: the service account token was revoked")
+
+	t.Run("each reference gets its own result", func(t *testing.T) {
+		provider := &ProviderOnePasswordSdk{client: newFakeClient().SDKClient()}
+		results := provider.GetSecrets(context.Background(), []esv1beta1.ExternalSecretDataRemoteRef{ref1, ref2, missing})
+		assert.Len(t, results, 3)
+		assert.Equal(t, SecretResult{Value: []byte(value1)}, results[ref1])
+		assert.Equal(t, SecretResult{Value: []byte(value2)}, results[ref2])
+		assert.ErrorIs(t, results[missing].Err, ErrSecretNotFound)
+	})
+
+	t.Run("signs in again once for the whole batch", func(t *testing.T) {
+		connected := newFakeClient()
+		var connects int
+		provider := &ProviderOnePasswordSdk{
+			client: newFakeClient().WithError(fake.SecretsResolve, errUnauthorized).SDKClient(),
+			connect: func(ctx context.Context) (*onepassword.Client, error) {
+				connects++
+				return connectTo(connected, nil)(ctx)
+			},
+		}
+		results := provider.GetSecrets(context.Background(), []esv1beta1.ExternalSecretDataRemoteRef{ref1, ref2})
+		assert.Equal(t, 1, connects)
+		assert.Equal(t, 2, connected.Calls[fake.SecretsResolve])
+		assert.Equal(t, SecretResult{Value: []byte(value1)}, results[ref1])
+		assert.Equal(t, SecretResult{Value: []byte(value2)}, results[ref2])
+	})
+
+	t.Run("rejected token is reported for every reference", func(t *testing.T) {
+		provider := &ProviderOnePasswordSdk{
+			client:  newFakeClient().WithError(fake.SecretsResolve, errUnauthorized).SDKClient(),
+			connect: connectTo(nil, errors.New(`secret "token" not found`)),
+		}
+		results := provider.GetSecrets(context.Background(), []esv1beta1.ExternalSecretDataRemoteRef{ref1, ref2})
+		assert.ErrorContains(t, results[ref1].Err, "token was revoked")
+		assert.ErrorContains(t, results[ref2].Err, "token was revoked")
+	})
+
+	t.Run("closed client", func(t *testing.T) {
+		provider := &ProviderOnePasswordSdk{client: newFakeClient().SDKClient()}
+		assert.NoError(t, provider.Close(context.Background()))
+		results := provider.GetSecrets(context.Background(), []esv1beta1.ExternalSecretDataRemoteRef{ref1})
+		assert.EqualError(t, results[ref1].Err, errClientClosed)
+	})
+}
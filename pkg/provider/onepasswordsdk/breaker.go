@@ -0,0 +1,230 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/1password/onepassword-sdk-go"
+	"github.com/prometheus/client_golang/prometheus"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	"github.com/external-secrets/external-secrets/pkg/cache"
+)
+
+const (
+	errCircuitOpen = "1Password calls through this store fail fast until %s, after %d calls in a row failed for 1Password being unavailable, see spec.provider.onepasswordsdk.circuitBreaker"
+
+	defaultCoolDown = 30 * time.Second
+)
+
+// breakerState is the state of a circuit breaker, as reported by the circuit breaker metric.
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+var (
+	// storeBreakers holds the circuit breaker of every store, see storeCacheKey.
+	storeBreakers   = cache.Must[*circuitBreaker](storeCacheSize, nil)
+	storeBreakersMu sync.Mutex
+)
+
+// circuitBreaker fails calls fast once threshold calls in a row failed for 1Password being
+// unavailable. Once open for coolDown, it lets a single call through, closing again when it
+// succeeds and opening for another coolDown when it fails.
+type circuitBreaker struct {
+	threshold int
+	coolDown  time.Duration
+	gauge     prometheus.Gauge
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+	// probing is set while the single call of a half-open breaker is in flight
+	probing bool
+}
+
+// storeBreaker returns the circuit breaker of the store for the namespace of the client,
+// creating it when needed.
+func storeBreaker(store esv1beta1.GenericStore, namespace string, config *esv1beta1.OnePasswordSdkCircuitBreaker) *circuitBreaker {
+	key, version := storeCacheKey(store, namespace)
+
+	storeBreakersMu.Lock()
+	defer storeBreakersMu.Unlock()
+	if breaker, ok := storeBreakers.Get(version, key); ok {
+		return breaker
+	}
+	breaker := newCircuitBreaker(config, circuitBreakerState.WithLabelValues(key.Kind, key.Name, key.Namespace))
+	storeBreakers.Add(version, key, breaker)
+	return breaker
+}
+
+func newCircuitBreaker(config *esv1beta1.OnePasswordSdkCircuitBreaker, gauge prometheus.Gauge) *circuitBreaker {
+	coolDown := defaultCoolDown
+	if config.CoolDown != nil && config.CoolDown.Duration > 0 {
+		coolDown = config.CoolDown.Duration
+	}
+	gauge.Set(float64(breakerClosed))
+	return &circuitBreaker{threshold: config.FailureThreshold, coolDown: coolDown, gauge: gauge, now: time.Now}
+}
+
+// allow fails with ErrCircuitOpen unless the call may go through, turning an open breaker whose
+// cool down is over half-open.
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case breakerOpen:
+		if b.now().Sub(b.openedAt) < b.coolDown {
+			return b.openErr()
+		}
+		b.setState(breakerHalfOpen)
+		b.probing = true
+		return nil
+	case breakerHalfOpen:
+		if b.probing {
+			return b.openErr()
+		}
+		b.probing = true
+		return nil
+	}
+	return nil
+}
+
+// done records the outcome of a call allow let through. Only 1Password being unavailable counts
+// as a failure: any other answer, such as a missing item, shows 1Password is up.
+func (b *circuitBreaker) done(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if errors.Is(err, context.Canceled) {
+		// the call tells nothing about 1Password, let the next one probe it instead
+		b.probing = false
+		return
+	}
+	if err == nil || !isUnavailable(err) {
+		b.failures = 0
+		b.probing = false
+		b.setState(breakerClosed)
+		return
+	}
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.openedAt = b.now()
+		b.probing = false
+		b.setState(breakerOpen)
+	}
+}
+
+func (b *circuitBreaker) setState(state breakerState) {
+	b.state = state
+	b.gauge.Set(float64(state))
+}
+
+func (b *circuitBreaker) openErr() error {
+	until := b.openedAt.Add(b.coolDown).UTC().Format(time.RFC3339)
+	return newTypedError(ErrCircuitOpen, fmt.Errorf(errCircuitOpen, until, b.failures))
+}
+
+// breakerCall calls fn unless breaker is open, recording its outcome.
+func breakerCall[T any](breaker *circuitBreaker, fn func() (T, error)) (T, error) {
+	if err := breaker.allow(); err != nil {
+		var zero T
+		return zero, err
+	}
+	value, err := fn()
+	breaker.done(err)
+	return value, err
+}
+
+// guardClient wraps every API of the SDK client so that each call to 1Password goes through
+// breaker. A nil breaker leaves the client as is.
+func guardClient(client onepassword.Client, breaker *circuitBreaker) onepassword.Client {
+	if breaker == nil {
+		return client
+	}
+	return onepassword.Client{
+		Secrets: &guardedSecrets{client.Secrets, breaker},
+		Items:   &guardedItems{client.Items, breaker},
+		Vaults:  &guardedVaults{client.Vaults, breaker},
+	}
+}
+
+type guardedSecrets struct {
+	onepassword.SecretsAPI
+	breaker *circuitBreaker
+}
+
+func (s *guardedSecrets) Resolve(ctx context.Context, secretReference string) (string, error) {
+	return breakerCall(s.breaker, func() (string, error) {
+		return s.SecretsAPI.Resolve(ctx, secretReference)
+	})
+}
+
+type guardedItems struct {
+	onepassword.ItemsAPI
+	breaker *circuitBreaker
+}
+
+func (i *guardedItems) Create(ctx context.Context, params onepassword.ItemCreateParams) (onepassword.Item, error) {
+	return breakerCall(i.breaker, func() (onepassword.Item, error) {
+		return i.ItemsAPI.Create(ctx, params)
+	})
+}
+
+func (i *guardedItems) Get(ctx context.Context, vaultID, itemID string) (onepassword.Item, error) {
+	return breakerCall(i.breaker, func() (onepassword.Item, error) {
+		return i.ItemsAPI.Get(ctx, vaultID, itemID)
+	})
+}
+
+func (i *guardedItems) Put(ctx context.Context, item onepassword.Item) (onepassword.Item, error) {
+	return breakerCall(i.breaker, func() (onepassword.Item, error) {
+		return i.ItemsAPI.Put(ctx, item)
+	})
+}
+
+func (i *guardedItems) Delete(ctx context.Context, vaultID, itemID string) error {
+	_, err := breakerCall(i.breaker, func() (struct{}, error) {
+		return struct{}{}, i.ItemsAPI.Delete(ctx, vaultID, itemID)
+	})
+	return err
+}
+
+func (i *guardedItems) ListAll(ctx context.Context, vaultID string) (*onepassword.Iterator[onepassword.ItemOverview], error) {
+	return breakerCall(i.breaker, func() (*onepassword.Iterator[onepassword.ItemOverview], error) {
+		return i.ItemsAPI.ListAll(ctx, vaultID)
+	})
+}
+
+type guardedVaults struct {
+	onepassword.VaultsAPI
+	breaker *circuitBreaker
+}
+
+func (v *guardedVaults) ListAll(ctx context.Context) (*onepassword.Iterator[onepassword.VaultOverview], error) {
+	return breakerCall(v.breaker, func() (*onepassword.Iterator[onepassword.VaultOverview], error) {
+		return v.VaultsAPI.ListAll(ctx)
+	})
+}
@@ -0,0 +1,131 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	"github.com/external-secrets/external-secrets/pkg/provider/onepasswordsdk/fake"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	ref := esv1beta1.ExternalSecretDataRemoteRef{Key: "op://my-vault/my-item/key1"}
+	unavailable := errors.New("service unavailable")
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_circuit_breaker_state"})
+	breaker := newCircuitBreaker(&esv1beta1.OnePasswordSdkCircuitBreaker{
+		FailureThreshold: 2,
+		CoolDown:         &metav1.Duration{Duration: time.Minute},
+	}, gauge)
+	now := time.Now()
+	breaker.now = func() time.Time { return now }
+	client := newFakeClient()
+	provider := &ProviderOnePasswordSdk{breaker: breaker}
+	provider.useClient(ptr.To(client.SDKClient()))
+
+	// a missing item shows 1Password is up
+	var err error
+	for range 3 {
+		_, err = provider.GetSecret(context.Background(), esv1beta1.ExternalSecretDataRemoteRef{Key: "op://my-vault/missing/key1"})
+		assert.ErrorIs(t, err, ErrSecretNotFound)
+	}
+	assert.Equal(t, float64(breakerClosed), testutil.ToFloat64(gauge))
+
+	// the breaker opens after 2 calls in a row failed for 1Password being unavailable
+	client.WithError(fake.SecretsResolve, unavailable)
+	calls := client.Calls[fake.SecretsResolve]
+	for range 2 {
+		_, err = provider.GetSecret(context.Background(), ref)
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrCircuitOpen)
+	}
+	assert.Equal(t, float64(breakerOpen), testutil.ToFloat64(gauge))
+
+	// and fails fast until its cool down is over
+	_, err = provider.GetSecret(context.Background(), ref)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, calls+2, client.Calls[fake.SecretsResolve])
+
+	// then a call failing again opens it for another cool down
+	now = now.Add(time.Minute)
+	_, err = provider.GetSecret(context.Background(), ref)
+	assert.NotErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, calls+3, client.Calls[fake.SecretsResolve])
+	assert.Equal(t, float64(breakerOpen), testutil.ToFloat64(gauge))
+	_, err = provider.GetSecret(context.Background(), ref)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+
+	now = now.Add(time.Minute - time.Second)
+	_, err = provider.GetSecret(context.Background(), ref)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+
+	// and a call succeeding closes it
+	now = now.Add(time.Second)
+	client.WithError(fake.SecretsResolve, nil)
+	got, err := provider.GetSecret(context.Background(), ref)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("value1"), got)
+	assert.Equal(t, float64(breakerClosed), testutil.ToFloat64(gauge))
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_circuit_breaker_state"})
+	breaker := newCircuitBreaker(&esv1beta1.OnePasswordSdkCircuitBreaker{
+		FailureThreshold: 1,
+		CoolDown:         &metav1.Duration{Duration: time.Minute},
+	}, gauge)
+	now := time.Now()
+	breaker.now = func() time.Time { return now }
+	assert.NoError(t, breaker.allow())
+	breaker.done(context.DeadlineExceeded)
+	assert.Equal(t, float64(breakerOpen), testutil.ToFloat64(gauge))
+	now = now.Add(time.Minute)
+
+	// a single call probes 1Password once half-open
+	assert.NoError(t, breaker.allow())
+	assert.Equal(t, float64(breakerHalfOpen), testutil.ToFloat64(gauge))
+	assert.ErrorIs(t, breaker.allow(), ErrCircuitOpen)
+
+	// a canceled probe lets the next call probe instead
+	breaker.done(context.Canceled)
+	assert.NoError(t, breaker.allow())
+	breaker.done(nil)
+	assert.Equal(t, float64(breakerClosed), testutil.ToFloat64(gauge))
+	assert.NoError(t, breaker.allow())
+}
+
+func TestStoreBreaker(t *testing.T) {
+	store := &esv1beta1.SecretStore{
+		TypeMeta:   metav1.TypeMeta{Kind: esv1beta1.SecretStoreKind},
+		ObjectMeta: metav1.ObjectMeta{Name: "broken-store", Namespace: "ns-a", ResourceVersion: "1"},
+	}
+	config := &esv1beta1.OnePasswordSdkCircuitBreaker{FailureThreshold: 3}
+	breaker := storeBreaker(store, "ns-a", config)
+	assert.Same(t, breaker, storeBreaker(store, "ns-a", config))
+	assert.Equal(t, 3, breaker.threshold)
+	assert.Equal(t, defaultCoolDown, breaker.coolDown)
+
+	store.ResourceVersion = "2"
+	assert.NotSame(t, breaker, storeBreaker(store, "ns-a", config))
+}
@@ -0,0 +1,329 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"context"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	"github.com/external-secrets/external-secrets/pkg/cache"
+)
+
+const (
+	storeCacheSize = 1024
+	cacheKeySep    = "\x00"
+)
+
+var (
+	// storeCaches holds the secret cache of every store, see storeCacheKey.
+	storeCaches   = cache.Must[*secretCache](storeCacheSize, nil)
+	storeCachesMu sync.Mutex
+)
+
+// secretCache holds the values read from 1Password for a store until their TTL expires.
+// A nil *secretCache is valid and caches nothing.
+type secretCache struct {
+	secrets *ttlCache[[]byte]
+	maps    *ttlCache[map[string][]byte]
+	ttls    cacheTTLs
+}
+
+// cacheTTLs picks the TTL each reference is cached for, out of cacheTTLRules and cacheTTL.
+type cacheTTLs struct {
+	defaultTTL   time.Duration
+	rules        []esv1beta1.OnePasswordSdkCacheTTLRule
+	defaultVault string
+	strictNames  bool
+}
+
+// storeSecretCache returns the cache of the store for the namespace of the client, creating
+// it when needed. The namespace is part of the key since a ClusterSecretStore may resolve
+// a different service account token in every namespace.
+func storeSecretCache(store esv1beta1.GenericStore, namespace string, ttls cacheTTLs) *secretCache {
+	key, version := storeCacheKey(store, namespace)
+
+	storeCachesMu.Lock()
+	defer storeCachesMu.Unlock()
+	if secretCache, ok := storeCaches.Get(version, key); ok {
+		return secretCache
+	}
+	secretCache := newSecretCache(ttls.defaultTTL)
+	secretCache.ttls = ttls
+	storeCaches.Add(version, key, secretCache)
+	return secretCache
+}
+
+// cacheEnabled reports whether the store caches the values it reads, for cacheTTL or a rule of
+// cacheTTLRules.
+func cacheEnabled(config *esv1beta1.OnePasswordSdkProvider) bool {
+	if config.CacheTTL != nil && config.CacheTTL.Duration > 0 {
+		return true
+	}
+	return slices.ContainsFunc(config.CacheTTLRules, func(rule esv1beta1.OnePasswordSdkCacheTTLRule) bool {
+		return rule.TTL.Duration > 0
+	})
+}
+
+// storeCacheKey keys the state kept for a store across clients, for the namespace of the client,
+// by the resource version of the store. A new client is created on every reconcile, so secret
+// caches, rate limiters, circuit breakers and resolve groups are kept per store and handed to each
+// client: the clients of a store share one budget of requests, stop calling 1Password at once, and
+// share calls in flight with no client of another store, which may see other items. Updating the
+// store changes its resource version, which drops that state.
+func storeCacheKey(store esv1beta1.GenericStore, namespace string) (cache.Key, string) {
+	return cache.Key{
+		Name:      store.GetObjectMeta().Name,
+		Namespace: namespace,
+		Kind:      store.GetKind(),
+	}, store.GetObjectMeta().ResourceVersion
+}
+
+func newSecretCache(ttl time.Duration) *secretCache {
+	return &secretCache{
+		secrets: newTTLCache[[]byte](ttl),
+		maps:    newTTLCache[map[string][]byte](ttl),
+		ttls:    cacheTTLs{defaultTTL: ttl},
+	}
+}
+
+// ttl returns the TTL of the rule of cacheTTLRules matching ref with the longest patterns, or
+// cacheTTL when none does.
+func (t cacheTTLs) ttl(ref esv1beta1.ExternalSecretDataRemoteRef) time.Duration {
+	if len(t.rules) == 0 {
+		return t.defaultTTL
+	}
+	parsed, err := parseSecretReference(ref.Key, t.defaultVault)
+	if err != nil {
+		return t.defaultTTL
+	}
+	ttl, longest := t.defaultTTL, -1
+	for _, rule := range t.rules {
+		if !globMatch(rule.Vault, parsed.vault, t.strictNames) || !globMatch(rule.Item, parsed.item, t.strictNames) {
+			continue
+		}
+		if length := len(rule.Vault) + len(rule.Item); length > longest {
+			ttl, longest = rule.TTL.Duration, length
+		}
+	}
+	return ttl
+}
+
+// globMatch reports whether name matches the shell glob pattern, ignoring case unless strict.
+// An empty pattern matches every name.
+func globMatch(pattern, name string, strict bool) bool {
+	if pattern == "" {
+		return true
+	}
+	if !strict {
+		pattern, name = strings.ToLower(pattern), strings.ToLower(name)
+	}
+	matched, _ := path.Match(pattern, name)
+	return matched
+}
+
+func (c *secretCache) getSecret(ref esv1beta1.ExternalSecretDataRemoteRef) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+	value, ok := c.secrets.get(secretCacheKey(ref))
+	return slices.Clone(value), ok
+}
+
+func (c *secretCache) addSecret(ref esv1beta1.ExternalSecretDataRemoteRef, value []byte) {
+	if c == nil {
+		return
+	}
+	c.secrets.addFor(secretCacheKey(ref), slices.Clone(value), c.ttls.ttl(ref))
+}
+
+func (c *secretCache) getSecretMap(ref esv1beta1.ExternalSecretDataRemoteRef) (map[string][]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+	value, ok := c.maps.get(secretCacheKey(ref))
+	return cloneSecretMap(value), ok
+}
+
+func (c *secretCache) addSecretMap(ref esv1beta1.ExternalSecretDataRemoteRef, value map[string][]byte) {
+	if c == nil {
+		return
+	}
+	c.maps.addFor(secretCacheKey(ref), cloneSecretMap(value), c.ttls.ttl(ref))
+}
+
+// secretCacheKey keys a value by every field of its remoteRef: besides selecting the value, the
+// strategies tell whether the defaults of the store decode and convert it, and how fields with
+// a decoding strategy of their own are encoded again.
+func secretCacheKey(ref esv1beta1.ExternalSecretDataRemoteRef) string {
+	return strings.Join([]string{
+		ref.Key,
+		ref.Property,
+		ref.Version,
+		string(ref.MetadataPolicy),
+		string(ref.ConversionStrategy),
+		string(ref.DecodingStrategy),
+	}, cacheKeySep)
+}
+
+func cloneSecretMap(secretMap map[string][]byte) map[string][]byte {
+	if secretMap == nil {
+		return nil
+	}
+	clone := maps.Clone(secretMap)
+	for key, value := range clone {
+		clone[key] = slices.Clone(value)
+	}
+	return clone
+}
+
+// revalidation runs a single background refresh at a time, which can be stopped and waited for.
+// The zero value is ready to use.
+type revalidation struct {
+	mu sync.Mutex
+	// run is the refresh underway, if any, so that a refresh stopped and then followed by
+	// another one does not mark the latter as done once it returns.
+	run    uint64
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// start runs refresh in the background with a context canceled by stop, unless a refresh is
+// already underway.
+func (r *revalidation) start(ctx context.Context, refresh func(ctx context.Context)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	r.run++
+	run := r.run
+	r.cancel = cancel
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer cancel()
+		refresh(ctx)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.run == run {
+			r.cancel = nil
+		}
+	}()
+}
+
+// stop cancels the refresh underway, if any, without waiting for it to return.
+func (r *revalidation) stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancel != nil {
+		r.cancel()
+		r.cancel = nil
+	}
+}
+
+// wait waits for every refresh started to return.
+func (r *revalidation) wait() {
+	r.wg.Wait()
+}
+
+// ttlCache is a map whose entries expire after a fixed TTL. It is safe for concurrent use.
+// A nil *ttlCache is valid and caches nothing.
+type ttlCache[T any] struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]ttlEntry[T]
+}
+
+type ttlEntry[T any] struct {
+	value   T
+	expires time.Time
+}
+
+func newTTLCache[T any](ttl time.Duration) *ttlCache[T] {
+	return &ttlCache[T]{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]ttlEntry[T]),
+	}
+}
+
+func (c *ttlCache[T]) get(key string) (T, bool) {
+	if c == nil {
+		var zero T
+		return zero, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || !c.now().Before(entry.expires) {
+		delete(c.entries, key)
+		var zero T
+		return zero, false
+	}
+	return entry.value, true
+}
+
+// add stores value under key, dropping every expired entry on the way so that
+// references no longer in use do not pile up.
+func (c *ttlCache[T]) add(key string, value T) {
+	if c == nil {
+		return
+	}
+	c.addFor(key, value, c.ttl)
+}
+
+// addFor is add with a TTL of its own for the entry. A TTL that is not positive stores nothing.
+func (c *ttlCache[T]) addFor(key string, value T, ttl time.Duration) {
+	if c == nil || ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	maps.DeleteFunc(c.entries, func(_ string, entry ttlEntry[T]) bool {
+		return !now.Before(entry.expires)
+	})
+	c.entries[key] = ttlEntry[T]{value: value, expires: now.Add(ttl)}
+}
+
+// getStale is get returning expired entries too, until they are replaced, reporting whether the
+// entry is still fresh.
+func (c *ttlCache[T]) getStale(key string) (value T, fresh, ok bool) {
+	if c == nil {
+		return value, false, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	return entry.value, ok && c.now().Before(entry.expires), ok
+}
+
+func (c *ttlCache[T]) delete(key string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
@@ -0,0 +1,293 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/1password/onepassword-sdk-go"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	"github.com/external-secrets/external-secrets/pkg/provider/onepasswordsdk/fake"
+)
+
+func TestTTLCache(t *testing.T) {
+	now := time.Now()
+	c := newTTLCache[string](time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.add("a", "1")
+	got, ok := c.get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "1", got)
+
+	now = now.Add(30 * time.Second)
+	c.add("b", "2")
+	now = now.Add(30 * time.Second)
+	_, ok = c.get("a")
+	assert.False(t, ok, "entry expired")
+	_, ok = c.get("b")
+	assert.True(t, ok)
+
+	now = now.Add(time.Minute)
+	c.add("c", "3")
+	assert.Len(t, c.entries, 1, "expired entries are dropped on add")
+}
+
+func TestGetSecretCached(t *testing.T) {
+	client := newFakeClient()
+	provider := &ProviderOnePasswordSdk{client: client.SDKClient(), cache: newSecretCache(time.Minute)}
+	fieldRef := esv1beta1.ExternalSecretDataRemoteRef{Key: "op://my-vault/my-item/key1"}
+	itemRef := esv1beta1.ExternalSecretDataRemoteRef{Key: "op://my-vault/my-item"}
+
+	value, err := provider.GetSecret(context.Background(), fieldRef)
+	assert.NoError(t, err)
+	secretMap, err := provider.GetSecretMap(context.Background(), itemRef)
+	assert.NoError(t, err)
+
+	// mutating the returned values or the item must not change what is served from the cache
+	value[0] = 'x'
+	secretMap[key1] = []byte("changed")
+	client.MockItems[myVaultID][0].Fields[0].Value = "changed"
+
+	value, err = provider.GetSecret(context.Background(), fieldRef)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(value1), value)
+	secretMap, err = provider.GetSecretMap(context.Background(), itemRef)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(value1), secretMap[key1])
+
+	// the property is part of the key
+	value, err = provider.GetSecret(context.Background(), esv1beta1.ExternalSecretDataRemoteRef{Key: "op://my-vault/my-item", Property: key1})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("changed"), value)
+
+	// without a cache every call reads from 1Password
+	provider.cache = nil
+	value, err = provider.GetSecret(context.Background(), fieldRef)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("changed"), value)
+}
+
+func TestGetSecretCachedStrategies(t *testing.T) {
+	client := newFakeClient()
+	client.MockItems[myVaultID][0].Fields[0].Value = "dmFsdWUx"
+	provider := &ProviderOnePasswordSdk{
+		client:          client.SDKClient(),
+		cache:           newSecretCache(time.Minute),
+		defaultDecoding: esv1beta1.ExternalSecretDecodeBase64,
+	}
+	// the default of the store decodes the value for None, and leaves it to the controller for Base64
+	for range 2 {
+		for _, tc := range []struct {
+			strategy esv1beta1.ExternalSecretDecodingStrategy
+			want     string
+		}{
+			{esv1beta1.ExternalSecretDecodeNone, value1},
+			{esv1beta1.ExternalSecretDecodeBase64, "dmFsdWUx"},
+		} {
+			value, err := provider.GetSecret(context.Background(), esv1beta1.ExternalSecretDataRemoteRef{Key: "op://my-vault/my-item/key1", DecodingStrategy: tc.strategy})
+			assert.NoError(t, err)
+			assert.Equal(t, []byte(tc.want), value, tc.strategy)
+		}
+	}
+}
+
+func TestSecretCacheKey(t *testing.T) {
+	ref := esv1beta1.ExternalSecretDataRemoteRef{Key: "op://my-vault/my-item"}
+	// every field of a remoteRef changes the value read, so each must change the key
+	refs := []esv1beta1.ExternalSecretDataRemoteRef{
+		ref,
+		{Key: ref.Key, Property: key1},
+		{Key: ref.Key, Version: "3"},
+		{Key: ref.Key, MetadataPolicy: esv1beta1.ExternalSecretMetadataPolicyFetch},
+		{Key: ref.Key, ConversionStrategy: esv1beta1.ExternalSecretConversionUnicode},
+		{Key: ref.Key, DecodingStrategy: esv1beta1.ExternalSecretDecodeBase64},
+	}
+	keys := map[string]struct{}{}
+	for _, ref := range refs {
+		keys[secretCacheKey(ref)] = struct{}{}
+	}
+	assert.Len(t, keys, len(refs))
+	assert.Equal(t, 6, reflect.TypeOf(ref).NumField(), "a new remoteRef field must be part of the cache key")
+}
+
+func TestCacheTTLRules(t *testing.T) {
+	rule := func(vault, item string, ttl time.Duration) esv1beta1.OnePasswordSdkCacheTTLRule {
+		return esv1beta1.OnePasswordSdkCacheTTLRule{Vault: vault, Item: item, TTL: metav1.Duration{Duration: ttl}}
+	}
+	ttls := cacheTTLs{
+		defaultTTL: time.Minute,
+		rules: []esv1beta1.OnePasswordSdkCacheTTLRule{
+			rule("prod-*", "", time.Hour),
+			rule("prod-*", "rotating-*", 5*time.Second),
+			rule("", "rotating-db", 10*time.Second),
+			rule("volatile", "", 0),
+		},
+		defaultVault: "prod-app",
+	}
+	tests := []struct {
+		name        string
+		key         string
+		strictNames bool
+		want        time.Duration
+	}{
+		{name: "no rule matches", key: "op://dev/my-item/key1", want: time.Minute},
+		{name: "vault rule", key: "op://prod-app/my-item/key1", want: time.Hour},
+		{name: "longest rule wins", key: "op://prod-app/rotating-token/key1", want: 5 * time.Second},
+		{name: "longest rule wins whatever its order", key: "op://prod-app/rotating-db", want: 5 * time.Second},
+		{name: "item rule", key: "op://dev/rotating-db/key1", want: 10 * time.Second},
+		{name: "default vault", key: "my-item/key1", want: time.Hour},
+		{name: "case is ignored", key: "op://Prod-App/my-item/key1", want: time.Hour},
+		{name: "strict names", key: "op://Prod-App/my-item/key1", strictNames: true, want: time.Minute},
+		{name: "uncached", key: "op://volatile/my-item/key1", want: 0},
+		{name: "invalid reference", key: "op://prod-app", want: time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ttls := ttls
+			ttls.strictNames = tt.strictNames
+			assert.Equal(t, tt.want, ttls.ttl(esv1beta1.ExternalSecretDataRemoteRef{Key: tt.key}))
+		})
+	}
+}
+
+func TestGetSecretCachedByRule(t *testing.T) {
+	client := newFakeClient()
+	secretCache := newSecretCache(0)
+	secretCache.ttls.rules = []esv1beta1.OnePasswordSdkCacheTTLRule{{Item: "my-*", TTL: metav1.Duration{Duration: time.Minute}}}
+	provider := &ProviderOnePasswordSdk{client: client.SDKClient(), cache: secretCache}
+	for range 2 {
+		_, err := provider.GetSecret(context.Background(), esv1beta1.ExternalSecretDataRemoteRef{Key: "op://my-vault/my-item/key1"})
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 1, client.Calls[fake.SecretsResolve])
+
+	// without a cacheTTL, the references no rule matches are not cached
+	client.AddItem(onepassword.Item{ID: "other-id", Title: "other", VaultID: myVaultID, Fields: []onepassword.ItemField{
+		{ID: "f1", Title: key1, FieldType: onepassword.ItemFieldTypeText, Value: value1},
+	}})
+	for range 2 {
+		_, err := provider.GetSecret(context.Background(), esv1beta1.ExternalSecretDataRemoteRef{Key: "op://my-vault/other/key1"})
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 3, client.Calls[fake.SecretsResolve])
+}
+
+func TestStoreSecretCache(t *testing.T) {
+	store := &esv1beta1.ClusterSecretStore{
+		TypeMeta:   metav1.TypeMeta{Kind: esv1beta1.ClusterSecretStoreKind},
+		ObjectMeta: metav1.ObjectMeta{Name: "cached-store", ResourceVersion: "1"},
+	}
+	c := storeSecretCache(store, "ns-a", cacheTTLs{defaultTTL: time.Minute})
+	assert.Same(t, c, storeSecretCache(store, "ns-a", cacheTTLs{defaultTTL: time.Minute}))
+	assert.NotSame(t, c, storeSecretCache(store, "ns-b", cacheTTLs{defaultTTL: time.Minute}))
+
+	store.ResourceVersion = "2"
+	assert.NotSame(t, c, storeSecretCache(store, "ns-a", cacheTTLs{defaultTTL: time.Minute}))
+}
+
+func TestVaultListCached(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient()
+	provider := &ProviderOnePasswordSdk{vaultList: newTTLCache[[]onepassword.VaultOverview](time.Minute)}
+	provider.useClient(ptr.To(client.SDKClient()))
+
+	_, err := provider.GetSecretMap(ctx, esv1beta1.ExternalSecretDataRemoteRef{Key: "op://my-vault/my-item"})
+	assert.NoError(t, err)
+	_, err = provider.GetSecret(ctx, esv1beta1.ExternalSecretDataRemoteRef{Key: "op://my-vault/my-item", Property: key1})
+	assert.NoError(t, err)
+	_, err = provider.Validate()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, client.Calls[fake.VaultsListAll])
+
+	// signing in again lists the vaults again
+	provider.useClient(ptr.To(client.SDKClient()))
+	_, err = provider.GetSecretMap(ctx, esv1beta1.ExternalSecretDataRemoteRef{Key: "op://my-vault/my-item"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, client.Calls[fake.VaultsListAll])
+
+	// without a cache every lookup lists the vaults
+	provider.vaultList = nil
+	_, err = provider.GetSecretMap(ctx, esv1beta1.ExternalSecretDataRemoteRef{Key: "op://my-vault/my-item"})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, client.Calls[fake.VaultsListAll])
+}
+
+func TestVaultListStaleWhileRevalidate(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient()
+	vaultList := newTTLCache[[]onepassword.VaultOverview](time.Minute)
+	now := time.Now()
+	vaultList.now = func() time.Time { return now }
+	provider := &ProviderOnePasswordSdk{vaultList: vaultList, revalidateVaults: true}
+	provider.useClient(ptr.To(client.SDKClient()))
+
+	vaults, err := provider.listVaults(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, vaults, 1)
+	assert.Equal(t, 1, client.Calls[fake.VaultsListAll])
+
+	// the expired list is served right away and refreshed in the background
+	now = now.Add(2 * time.Minute)
+	client.AddVault("newVaultID", "new-vault")
+	vaults, err = provider.listVaults(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, vaults, 1)
+	provider.revalidation.wait()
+	assert.Equal(t, 2, client.Calls[fake.VaultsListAll])
+
+	vaults, err = provider.listVaults(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, vaults, 2)
+	assert.Equal(t, 2, client.Calls[fake.VaultsListAll])
+
+	// a failed refresh keeps serving the expired list
+	now = now.Add(2 * time.Minute)
+	client.WithError(fake.VaultsListAll, errors.New("service unavailable"))
+	vaults, err = provider.listVaults(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, vaults, 2)
+	provider.revalidation.wait()
+	assert.NoError(t, provider.Close(ctx))
+}
+
+func TestRevalidation(t *testing.T) {
+	var (
+		r       revalidation
+		started int
+	)
+	block := func(ctx context.Context) {
+		started++
+		<-ctx.Done()
+	}
+	r.start(context.Background(), block)
+	// a refresh is already underway
+	r.start(context.Background(), block)
+	r.stop()
+	r.wait()
+	assert.Equal(t, 1, started)
+
+	r.start(context.Background(), func(context.Context) { started++ })
+	r.wait()
+	assert.Equal(t, 2, started)
+}
@@ -0,0 +1,92 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/1password/onepassword-sdk-go"
+	"golang.org/x/sync/singleflight"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	"github.com/external-secrets/external-secrets/pkg/cache"
+)
+
+var (
+	// storeResolveGroups holds the resolve group of every store, see storeCacheKey.
+	storeResolveGroups   = cache.Must[*singleflight.Group](storeCacheSize, nil)
+	storeResolveGroupsMu sync.Mutex
+)
+
+// storeResolveGroup returns the group coalescing the resolves of the store for the namespace of
+// the client, creating it when needed.
+func storeResolveGroup(store esv1beta1.GenericStore, namespace string) *singleflight.Group {
+	key, version := storeCacheKey(store, namespace)
+
+	storeResolveGroupsMu.Lock()
+	defer storeResolveGroupsMu.Unlock()
+	if group, ok := storeResolveGroups.Get(version, key); ok {
+		return group
+	}
+	group := &singleflight.Group{}
+	storeResolveGroups.Add(version, key, group)
+	return group
+}
+
+// coalesceClient wraps the Secrets API of the SDK client so that concurrent resolves of the same
+// secret reference share a single call to 1Password, such as when the ExternalSecrets of a store
+// referencing the same item all refresh at once. A nil group leaves the client as is.
+func coalesceClient(client onepassword.Client, group *singleflight.Group) onepassword.Client {
+	if group == nil {
+		return client
+	}
+	client.Secrets = &coalescedSecrets{client.Secrets, group}
+	return client
+}
+
+// resolveGroup is the part of *singleflight.Group coalescedSecrets uses.
+type resolveGroup interface {
+	DoChan(key string, fn func() (any, error)) <-chan singleflight.Result
+}
+
+type coalescedSecrets struct {
+	onepassword.SecretsAPI
+	group resolveGroup
+}
+
+// Resolve joins the call in flight for secretReference, if any. The call runs with the context of
+// the caller that started it: the others stop waiting once their own context is done, and make
+// their own call rather than fail with the context of another caller.
+func (s *coalescedSecrets) Resolve(ctx context.Context, secretReference string) (string, error) {
+	results := s.group.DoChan(secretReference, func() (any, error) {
+		return s.SecretsAPI.Resolve(ctx, secretReference)
+	})
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case result := <-results:
+		if result.Shared && isContextError(result.Err) && ctx.Err() == nil {
+			return s.SecretsAPI.Resolve(ctx, secretReference)
+		}
+		value, _ := result.Val.(string)
+		return value, result.Err
+	}
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
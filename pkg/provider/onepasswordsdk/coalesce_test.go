@@ -0,0 +1,129 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/1password/onepassword-sdk-go"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sync/singleflight"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+)
+
+// blockingSecrets resolves every reference to its own value once released.
+type blockingSecrets struct {
+	release chan struct{}
+	calls   atomic.Int32
+}
+
+func (s *blockingSecrets) Resolve(ctx context.Context, secretReference string) (string, error) {
+	s.calls.Add(1)
+	select {
+	case <-s.release:
+		return secretReference, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// countingGroup counts the callers that started or joined a call.
+type countingGroup struct {
+	singleflight.Group
+	callers atomic.Int32
+}
+
+func (g *countingGroup) DoChan(key string, fn func() (any, error)) <-chan singleflight.Result {
+	results := g.Group.DoChan(key, fn)
+	g.callers.Add(1)
+	return results
+}
+
+func TestCoalesceResolve(t *testing.T) {
+	const ref = "op://my-vault/my-item/key1"
+
+	t.Run("concurrent resolves share one call", func(t *testing.T) {
+		secrets := &blockingSecrets{release: make(chan struct{})}
+		group := &countingGroup{}
+		client := onepassword.Client{Secrets: &coalescedSecrets{secrets, group}}
+		var wg sync.WaitGroup
+		values := make([]string, 5)
+		for i := range values {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				values[i], _ = client.Secrets.Resolve(context.Background(), ref)
+			}(i)
+		}
+		// let every caller join the call in flight before it returns
+		assert.Eventually(t, func() bool { return group.callers.Load() == 5 }, time.Second, time.Millisecond)
+		close(secrets.release)
+		wg.Wait()
+		assert.Equal(t, int32(1), secrets.calls.Load())
+		assert.Equal(t, []string{ref, ref, ref, ref, ref}, values)
+	})
+
+	t.Run("other references are not shared", func(t *testing.T) {
+		secrets := &blockingSecrets{release: make(chan struct{})}
+		close(secrets.release)
+		client := coalesceClient(onepassword.Client{Secrets: secrets}, &singleflight.Group{})
+		got, err := client.Secrets.Resolve(context.Background(), ref)
+		assert.NoError(t, err)
+		assert.Equal(t, ref, got)
+		got, err = client.Secrets.Resolve(context.Background(), "op://my-vault/my-item/key2")
+		assert.NoError(t, err)
+		assert.Equal(t, "op://my-vault/my-item/key2", got)
+		assert.Equal(t, int32(2), secrets.calls.Load())
+	})
+
+	t.Run("cancelled caller does not fail the others", func(t *testing.T) {
+		secrets := &blockingSecrets{release: make(chan struct{})}
+		group := &countingGroup{}
+		client := onepassword.Client{Secrets: &coalescedSecrets{secrets, group}}
+		ctx, cancel := context.WithCancel(context.Background())
+		first := make(chan error, 1)
+		go func() {
+			_, err := client.Secrets.Resolve(ctx, ref)
+			first <- err
+		}()
+		assert.Eventually(t, func() bool { return secrets.calls.Load() == 1 }, time.Second, time.Millisecond)
+		second := make(chan string, 1)
+		go func() {
+			value, _ := client.Secrets.Resolve(context.Background(), ref)
+			second <- value
+		}()
+		assert.Eventually(t, func() bool { return group.callers.Load() == 2 }, time.Second, time.Millisecond)
+		cancel()
+		assert.ErrorIs(t, <-first, context.Canceled)
+		close(secrets.release)
+		assert.Equal(t, ref, <-second)
+	})
+}
+
+func TestStoreResolveGroup(t *testing.T) {
+	store := &esv1beta1.SecretStore{
+		TypeMeta:   metav1.TypeMeta{Kind: esv1beta1.SecretStoreKind},
+		ObjectMeta: metav1.ObjectMeta{Name: "coalesced-store", Namespace: "ns-a", ResourceVersion: "1"},
+	}
+	group := storeResolveGroup(store, "ns-a")
+	assert.Same(t, group, storeResolveGroup(store, "ns-a"))
+	assert.NotSame(t, group, storeResolveGroup(store, "ns-b"))
+}
@@ -0,0 +1,412 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	opconnect "github.com/1Password/connect-sdk-go/connect"
+	connectmodel "github.com/1Password/connect-sdk-go/onepassword"
+	"github.com/1password/onepassword-sdk-go"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	"github.com/external-secrets/external-secrets/pkg/utils/resolvers"
+)
+
+const (
+	errConnectVaultNotFound = "vault %q not found on the 1Password Connect server"
+	errConnectItemNotFound  = "item %q not found in vault %q on the 1Password Connect server"
+	errConnectFieldNotFound = "field %q not found in item %q on the 1Password Connect server"
+	errConnectMoreThanOne   = "more than one %s matched the secret reference %q"
+	errConnectAttribute     = "attribute %q of secret reference %q is not supported through a 1Password Connect server"
+)
+
+// connectCategories maps the item categories of Connect to those of the SDK. Items of any other
+// category are Unsupported, as they are in the SDK.
+var connectCategories = map[connectmodel.ItemCategory]onepassword.ItemCategory{
+	connectmodel.Login:                onepassword.ItemCategoryLogin,
+	connectmodel.Password:             onepassword.ItemCategoryPassword,
+	connectmodel.ApiCredential:        onepassword.ItemCategoryAPICredentials,
+	connectmodel.Server:               onepassword.ItemCategoryServer,
+	connectmodel.Database:             onepassword.ItemCategoryDatabase,
+	connectmodel.CreditCard:           onepassword.ItemCategoryCreditCard,
+	connectmodel.Membership:           onepassword.ItemCategoryMembership,
+	connectmodel.Passport:             onepassword.ItemCategoryPassport,
+	connectmodel.SoftwareLicense:      onepassword.ItemCategorySoftwareLicense,
+	connectmodel.OutdoorLicense:       onepassword.ItemCategoryOutdoorLicense,
+	connectmodel.SecureNote:           onepassword.ItemCategorySecureNote,
+	connectmodel.WirelessRouter:       onepassword.ItemCategoryRouter,
+	connectmodel.BankAccount:          onepassword.ItemCategoryBankAccount,
+	connectmodel.DriverLicense:        onepassword.ItemCategoryDriverLicense,
+	connectmodel.Identity:             onepassword.ItemCategoryIdentity,
+	connectmodel.RewardProgram:        onepassword.ItemCategoryRewards,
+	connectmodel.Document:             onepassword.ItemCategoryDocument,
+	connectmodel.EmailAccount:         onepassword.ItemCategoryEmail,
+	connectmodel.SocialSecurityNumber: onepassword.ItemCategorySocialSecurityNumber,
+	connectmodel.MedicalRecord:        onepassword.ItemCategoryMedicalRecord,
+	connectmodel.SSHKey:               onepassword.ItemCategorySSHKey,
+}
+
+// connectFieldTypes maps the field types of Connect to those of the SDK. Fields of any other
+// type are Unsupported, as they are in the SDK.
+var connectFieldTypes = map[connectmodel.ItemFieldType]onepassword.ItemFieldType{
+	connectmodel.FieldTypeString:         onepassword.ItemFieldTypeText,
+	connectmodel.FieldTypeConcealed:      onepassword.ItemFieldTypeConcealed,
+	connectmodel.FieldTypeCreditCardType: onepassword.ItemFieldTypeCreditCardType,
+	connectmodel.FieldTypePhone:          onepassword.ItemFieldTypePhone,
+	connectmodel.FieldTypeURL:            onepassword.ItemFieldTypeURL,
+	connectmodel.FieldTypeOTP:            onepassword.ItemFieldTypeTOTP,
+}
+
+// newConnectServerFunc returns a connectFunc building a client that goes through the 1Password
+// Connect server of the store, authenticated with the Connect token. Connect tokens have no
+// fallback: there is no sign in, a rejected token only shows up with the first request.
+func newConnectServerFunc(config *esv1beta1.OnePasswordSdkProvider, kube client.Client, storeKind, namespace string) connectFunc {
+	return func(ctx context.Context) (*onepassword.Client, error) {
+		token, err := resolvers.SecretKeyRef(ctx, kube, storeKind, namespace, config.Auth.ConnectTokenSecretRef)
+		if err != nil {
+			return nil, err
+		}
+		name, version := integrationInfo(config)
+		sdkClient := connectClient(opconnect.NewClientWithUserAgent(config.ConnectHost, strings.TrimSpace(token), name+"/"+version))
+		return &sdkClient, nil
+	}
+}
+
+// connectClient returns an onepassword.Client whose APIs go through a 1Password Connect server,
+// with the items of Connect mapped to those of the SDK, so that the rest of the provider does not
+// tell the two apart. The Connect client takes no context: a request to the server is not
+// cancelled along with ctx, it runs up to the timeout of the HTTP client.
+func connectClient(client opconnect.Client) onepassword.Client {
+	c := &connectAPI{client: client}
+	return onepassword.Client{
+		Secrets: &connectSecrets{c},
+		Items:   &connectItems{c},
+		Vaults:  &connectVaults{c},
+	}
+}
+
+type connectAPI struct {
+	client opconnect.Client
+}
+
+// connectError prefixes the errors of the Connect API with the text of their HTTP status, such
+// as not found or unauthorized, which is what errors are told apart by in this package.
+func connectError(err error) error {
+	var apiErr *connectmodel.Error
+	if errors.As(err, &apiErr) {
+		if text := http.StatusText(apiErr.StatusCode); text != "" {
+			return fmt.Errorf("%s: %w", strings.ToLower(text), err)
+		}
+	}
+	return err
+}
+
+// findItem returns the item whose ID or title equals itemQuery, in the vault whose ID or title
+// equals vaultQuery, like the SDK resolves secret references.
+func (c *connectAPI) findItem(ref string, vaultQuery, itemQuery string) (*connectmodel.Item, error) {
+	vaults, err := c.client.GetVaults()
+	if err != nil {
+		return nil, connectError(err)
+	}
+	var vaultIDs []string
+	for _, vault := range vaults {
+		if vault.ID == vaultQuery || vault.Name == vaultQuery {
+			vaultIDs = append(vaultIDs, vault.ID)
+		}
+	}
+	switch len(vaultIDs) {
+	case 0:
+		return nil, newTypedError(ErrVaultNotFound, fmt.Errorf(errConnectVaultNotFound, vaultQuery))
+	case 1:
+	default:
+		return nil, fmt.Errorf(errConnectMoreThanOne, "vault", ref)
+	}
+
+	items, err := c.client.GetItems(vaultIDs[0])
+	if err != nil {
+		return nil, connectError(err)
+	}
+	var itemIDs []string
+	for _, item := range items {
+		if !item.Trashed && (item.ID == itemQuery || item.Title == itemQuery) {
+			itemIDs = append(itemIDs, item.ID)
+		}
+	}
+	switch len(itemIDs) {
+	case 0:
+		return nil, newTypedError(ErrSecretNotFound, fmt.Errorf(errConnectItemNotFound, itemQuery, vaultQuery))
+	case 1:
+	default:
+		return nil, fmt.Errorf(errConnectMoreThanOne, "item", ref)
+	}
+	item, err := c.client.GetItemByUUID(itemIDs[0], vaultIDs[0])
+	if err != nil {
+		return nil, connectError(err)
+	}
+	return item, nil
+}
+
+type connectSecrets struct {
+	*connectAPI
+}
+
+// Resolve returns the value of the field of op://<vault>/<item>[/<section>]/<field>, one-time
+// passwords resolving to their otpauth:// URI as with the SDK.
+func (s *connectSecrets) Resolve(_ context.Context, secretReference string) (string, error) {
+	ref, err := parseSecretReference(secretReference, "")
+	if err != nil {
+		return "", err
+	}
+	if ref.field == "" {
+		return "", fmt.Errorf(errInvalidSecretReference, secretReference)
+	}
+	if ref.attribute != "" {
+		return "", fmt.Errorf(errConnectAttribute, ref.attribute, secretReference)
+	}
+	connectItem, err := s.findItem(secretReference, ref.vault, ref.item)
+	if err != nil {
+		return "", err
+	}
+	item := toSDKItem(connectItem)
+	var values []string
+	for _, field := range item.Fields {
+		if field.ID != ref.field && field.Title != ref.field {
+			continue
+		}
+		if ref.section != "" && !inItemSection(&item, field, ref.section) {
+			continue
+		}
+		values = append(values, field.Value)
+	}
+	switch len(values) {
+	case 0:
+		return "", newTypedError(ErrSecretNotFound, fmt.Errorf(errConnectFieldNotFound, ref.field, ref.item))
+	case 1:
+		return values[0], nil
+	default:
+		return "", fmt.Errorf(errConnectMoreThanOne, "field", secretReference)
+	}
+}
+
+// inItemSection reports whether field is in the section of item whose ID or title is section.
+func inItemSection(item *onepassword.Item, field onepassword.ItemField, section string) bool {
+	if field.SectionID == nil {
+		return false
+	}
+	for _, s := range item.Sections {
+		if s.ID == *field.SectionID && (s.ID == section || s.Title == section) {
+			return true
+		}
+	}
+	return false
+}
+
+type connectItems struct {
+	*connectAPI
+}
+
+func (i *connectItems) Create(_ context.Context, params onepassword.ItemCreateParams) (onepassword.Item, error) {
+	item := toConnectItem(onepassword.Item{
+		Title:    params.Title,
+		Category: params.Category,
+		VaultID:  params.VaultID,
+		Fields:   params.Fields,
+		Sections: params.Sections,
+		Tags:     params.Tags,
+	}, nil)
+	created, err := i.client.CreateItem(item, params.VaultID)
+	if err != nil {
+		return onepassword.Item{}, connectError(err)
+	}
+	return toSDKItem(created), nil
+}
+
+func (i *connectItems) Get(_ context.Context, vaultID, itemID string) (onepassword.Item, error) {
+	item, err := i.client.GetItemByUUID(itemID, vaultID)
+	if err != nil {
+		return onepassword.Item{}, connectError(err)
+	}
+	return toSDKItem(item), nil
+}
+
+// Put updates the item in place of the current one, which is read again so that what the SDK
+// model leaves out, such as the purpose of a field, is kept.
+func (i *connectItems) Put(_ context.Context, item onepassword.Item) (onepassword.Item, error) {
+	current, err := i.client.GetItemByUUID(item.ID, item.VaultID)
+	if err != nil {
+		return onepassword.Item{}, connectError(err)
+	}
+	updated, err := i.client.UpdateItem(toConnectItem(item, current), item.VaultID)
+	if err != nil {
+		return onepassword.Item{}, connectError(err)
+	}
+	return toSDKItem(updated), nil
+}
+
+func (i *connectItems) Delete(_ context.Context, vaultID, itemID string) error {
+	return connectError(i.client.DeleteItemByID(itemID, vaultID))
+}
+
+func (i *connectItems) ListAll(_ context.Context, vaultID string) (*onepassword.Iterator[onepassword.ItemOverview], error) {
+	items, err := i.client.GetItems(vaultID)
+	if err != nil {
+		return nil, connectError(err)
+	}
+	overviews := make([]onepassword.ItemOverview, 0, len(items))
+	for _, item := range items {
+		if item.Trashed {
+			continue
+		}
+		overviews = append(overviews, onepassword.ItemOverview{
+			ID:       item.ID,
+			Title:    item.Title,
+			Category: sdkCategory(item.Category),
+			VaultID:  vaultID,
+		})
+	}
+	return onepassword.NewIterator(overviews), nil
+}
+
+type connectVaults struct {
+	*connectAPI
+}
+
+func (v *connectVaults) ListAll(_ context.Context) (*onepassword.Iterator[onepassword.VaultOverview], error) {
+	vaults, err := v.client.GetVaults()
+	if err != nil {
+		return nil, connectError(err)
+	}
+	overviews := make([]onepassword.VaultOverview, 0, len(vaults))
+	for _, vault := range vaults {
+		overviews = append(overviews, onepassword.VaultOverview{ID: vault.ID, Title: vault.Name})
+	}
+	return onepassword.NewIterator(overviews), nil
+}
+
+func sdkCategory(category connectmodel.ItemCategory) onepassword.ItemCategory {
+	if sdk, ok := connectCategories[category]; ok {
+		return sdk
+	}
+	return onepassword.ItemCategoryUnsupported
+}
+
+func connectCategory(category onepassword.ItemCategory) connectmodel.ItemCategory {
+	for c, sdk := range connectCategories {
+		if sdk == category {
+			return c
+		}
+	}
+	return connectmodel.Custom
+}
+
+func connectFieldType(fieldType onepassword.ItemFieldType) (connectmodel.ItemFieldType, bool) {
+	for c, sdk := range connectFieldTypes {
+		if sdk == fieldType {
+			return c, true
+		}
+	}
+	return "", false
+}
+
+// toSDKItem maps an item of Connect to the SDK model. The notes of the item are Unsupported, as
+// the SDK leaves them out of the fields it models: they are only read through their reference.
+func toSDKItem(item *connectmodel.Item) onepassword.Item {
+	sdkItem := onepassword.Item{
+		ID:       item.ID,
+		Title:    item.Title,
+		Category: sdkCategory(item.Category),
+		VaultID:  item.Vault.ID,
+		Tags:     item.Tags,
+		Version:  uint32(item.Version), //nolint:gosec // versions are small positive numbers
+	}
+	for _, section := range item.Sections {
+		if section != nil {
+			sdkItem.Sections = append(sdkItem.Sections, onepassword.ItemSection{ID: section.ID, Title: section.Label})
+		}
+	}
+	for _, field := range item.Fields {
+		if field == nil {
+			continue
+		}
+		fieldType, ok := connectFieldTypes[field.Type]
+		if !ok || field.Purpose == connectmodel.FieldPurposeNotes {
+			fieldType = onepassword.ItemFieldTypeUnsupported
+		}
+		sdkField := onepassword.ItemField{ID: field.ID, Title: field.Label, FieldType: fieldType, Value: field.Value}
+		if field.Section != nil && field.Section.ID != "" {
+			sectionID := field.Section.ID
+			sdkField.SectionID = &sectionID
+		}
+		if fieldType == onepassword.ItemFieldTypeTOTP {
+			code := field.TOTP
+			details := onepassword.NewItemFieldDetailsTypeVariantOTP(&onepassword.OTPFieldDetails{Code: &code})
+			sdkField.Details = &details
+		}
+		sdkItem.Fields = append(sdkItem.Fields, sdkField)
+	}
+	return sdkItem
+}
+
+// toConnectItem maps an item of the SDK model to Connect, on top of the current item when it
+// exists: its fields keep what the SDK model has no room for, and their type unless it changed.
+func toConnectItem(item onepassword.Item, current *connectmodel.Item) *connectmodel.Item {
+	connectItem := &connectmodel.Item{Category: connectCategory(item.Category)}
+	currentFields := map[string]*connectmodel.ItemField{}
+	if current != nil {
+		*connectItem = *current
+		for _, field := range current.Fields {
+			if field != nil && field.ID != "" {
+				currentFields[field.ID] = field
+			}
+		}
+	}
+	connectItem.ID = item.ID
+	connectItem.Title = item.Title
+	connectItem.Vault = connectmodel.ItemVault{ID: item.VaultID}
+	connectItem.Tags = item.Tags
+	connectItem.Version = int(item.Version)
+
+	labels := make(map[string]string, len(item.Sections))
+	connectItem.Sections = make([]*connectmodel.ItemSection, 0, len(item.Sections))
+	for _, section := range item.Sections {
+		labels[section.ID] = section.Title
+		connectItem.Sections = append(connectItem.Sections, &connectmodel.ItemSection{ID: section.ID, Label: section.Title})
+	}
+	connectItem.Fields = make([]*connectmodel.ItemField, 0, len(item.Fields))
+	for _, field := range item.Fields {
+		connectField := &connectmodel.ItemField{Type: connectmodel.FieldTypeString}
+		if current, ok := currentFields[field.ID]; ok {
+			*connectField = *current
+		}
+		connectField.ID = field.ID
+		connectField.Label = field.Title
+		connectField.Value = field.Value
+		if fieldType, ok := connectFieldType(field.FieldType); ok && connectFieldTypes[connectField.Type] != field.FieldType {
+			connectField.Type = fieldType
+		}
+		connectField.Section = nil
+		if field.SectionID != nil {
+			connectField.Section = &connectmodel.ItemSection{ID: *field.SectionID, Label: labels[*field.SectionID]}
+		}
+		connectItem.Fields = append(connectItem.Fields, connectField)
+	}
+	return connectItem
+}
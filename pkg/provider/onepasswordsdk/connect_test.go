@@ -0,0 +1,170 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	opconnect "github.com/1Password/connect-sdk-go/connect"
+	connectmodel "github.com/1Password/connect-sdk-go/onepassword"
+	"github.com/1password/onepassword-sdk-go"
+	"github.com/stretchr/testify/assert"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	testingfake "github.com/external-secrets/external-secrets/pkg/provider/testing/fake"
+)
+
+// fakeConnect serves the few Connect API calls the adapter makes out of memory. Any other call
+// panics on the nil embedded client.
+type fakeConnect struct {
+	opconnect.Client
+	vaults []connectmodel.Vault
+	items  []connectmodel.Item
+}
+
+func newFakeConnect() *fakeConnect {
+	return &fakeConnect{
+		vaults: []connectmodel.Vault{{ID: myVaultID, Name: myVault}},
+		items: []connectmodel.Item{{
+			ID:       myItemID,
+			Title:    myItem,
+			Category: connectmodel.Login,
+			Vault:    connectmodel.ItemVault{ID: myVaultID},
+			Version:  3,
+			Fields: []*connectmodel.ItemField{
+				{ID: "f1", Label: key1, Type: connectmodel.FieldTypeConcealed, Purpose: connectmodel.FieldPurposePassword, Value: value1},
+				{ID: "f2", Label: key2, Type: connectmodel.FieldTypeString, Value: value2},
+				{ID: notesPlain, Label: "notesPlain", Type: connectmodel.FieldTypeString, Purpose: connectmodel.FieldPurposeNotes, Value: "notes"},
+			},
+		}},
+	}
+}
+
+func (c *fakeConnect) GetVaults() ([]connectmodel.Vault, error) {
+	return c.vaults, nil
+}
+
+func (c *fakeConnect) GetItems(vaultID string) ([]connectmodel.Item, error) {
+	var items []connectmodel.Item
+	for _, item := range c.items {
+		if item.Vault.ID == vaultID {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func (c *fakeConnect) GetItemByUUID(itemID, vaultID string) (*connectmodel.Item, error) {
+	for i := range c.items {
+		if c.items[i].ID == itemID && c.items[i].Vault.ID == vaultID {
+			item := c.items[i]
+			return &item, nil
+		}
+	}
+	return nil, &connectmodel.Error{StatusCode: http.StatusNotFound, Message: "Item is not in vault"}
+}
+
+func (c *fakeConnect) CreateItem(item *connectmodel.Item, vaultID string) (*connectmodel.Item, error) {
+	created := *item
+	created.ID = item.Title + "-id"
+	created.Vault = connectmodel.ItemVault{ID: vaultID}
+	created.Version = 1
+	c.items = append(c.items, created)
+	return &created, nil
+}
+
+func (c *fakeConnect) UpdateItem(item *connectmodel.Item, vaultID string) (*connectmodel.Item, error) {
+	for i := range c.items {
+		if c.items[i].ID == item.ID && c.items[i].Vault.ID == vaultID {
+			updated := *item
+			updated.Version = c.items[i].Version + 1
+			c.items[i] = updated
+			return &updated, nil
+		}
+	}
+	return nil, &connectmodel.Error{StatusCode: http.StatusNotFound, Message: "Item is not in vault"}
+}
+
+func TestConnectGetSecret(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		want    string
+		wantErr error
+	}{
+		{name: "field label", key: "op://my-vault/my-item/key1", want: value1},
+		{name: "field ID and item ID", key: "op://my-vault-id/my-item-id/f2", want: value2},
+		{name: "missing vault", key: "op://missing/my-item/key1", wantErr: ErrVaultNotFound},
+		{name: "missing item", key: "op://my-vault/missing/key1", wantErr: ErrSecretNotFound},
+		{name: "missing field", key: "op://my-vault/my-item/missing", wantErr: ErrSecretNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &ProviderOnePasswordSdk{client: connectClient(newFakeConnect())}
+			got, err := provider.GetSecret(context.Background(), esv1beta1.ExternalSecretDataRemoteRef{Key: tt.key})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestConnectGetSecretMap(t *testing.T) {
+	provider := &ProviderOnePasswordSdk{client: connectClient(newFakeConnect())}
+	got, err := provider.GetSecretMap(context.Background(), esv1beta1.ExternalSecretDataRemoteRef{Key: "op://my-vault/my-item"})
+	assert.NoError(t, err)
+	// the notes are only read through their reference, as with the SDK
+	assert.Equal(t, map[string][]byte{key1: []byte(value1), key2: []byte(value2), notesPlain: []byte("notes")}, got)
+}
+
+func TestConnectPushSecret(t *testing.T) {
+	connect := newFakeConnect()
+	provider := &ProviderOnePasswordSdk{client: connectClient(connect)}
+
+	err := provider.PushSecret(context.Background(), newPushSecret(), testingfake.PushSecretData{RemoteKey: "op://my-vault/new-item", SecretKey: key1})
+	assert.NoError(t, err)
+	if assert.Len(t, connect.items, 2) {
+		created := connect.items[1]
+		assert.Equal(t, "new-item", created.Title)
+		assert.Equal(t, connectmodel.ApiCredential, created.Category)
+		if assert.Len(t, created.Fields, 1) {
+			assert.Equal(t, value1, created.Fields[0].Value)
+		}
+	}
+
+	err = provider.PushSecret(context.Background(), newPushSecret(), testingfake.PushSecretData{RemoteKey: "op://my-vault/my-item", SecretKey: key2, Property: key1})
+	assert.NoError(t, err)
+	updated := connect.items[0]
+	assert.Equal(t, 4, updated.Version)
+	assert.Equal(t, value2, updated.Fields[0].Value)
+	// what the SDK model has no room for is kept
+	assert.Equal(t, connectmodel.FieldPurposePassword, updated.Fields[0].Purpose)
+	assert.Equal(t, connectmodel.FieldPurposeNotes, updated.Fields[2].Purpose)
+}
+
+func TestConnectItemMapping(t *testing.T) {
+	item := toSDKItem(&newFakeConnect().items[0])
+	assert.Equal(t, onepassword.ItemCategoryLogin, item.Category)
+	assert.Equal(t, uint32(3), item.Version)
+	assert.Equal(t, onepassword.ItemFieldTypeConcealed, item.Fields[0].FieldType)
+	assert.Equal(t, onepassword.ItemFieldTypeText, item.Fields[1].FieldType)
+	assert.Equal(t, onepassword.ItemFieldTypeUnsupported, item.Fields[2].FieldType)
+	assert.Equal(t, connectmodel.Custom, connectCategory(onepassword.ItemCategoryUnsupported))
+}
@@ -0,0 +1,84 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/1password/onepassword-sdk-go"
+)
+
+const (
+	errSDKCore = "the 1Password SDK %s could not load its core, which it needs to sign in with a service account token: %v; " +
+		"check that the controller allows WebAssembly to run, with enough memory for it, or use a 1Password Connect server with spec.provider.onepasswordsdk.connectHost"
+
+	// sdkInitError prefixes the errors of the SDK once its core is loaded, such as for a missing token.
+	sdkInitError = "error initializing client"
+
+	// coreProbeTimeout bounds loading the core of the SDK, which compiles its WebAssembly module.
+	coreProbeTimeout = 30 * time.Second
+)
+
+// sdkCore checks the core of the SDK for the process.
+var sdkCore coreCheck
+
+// coreCheck remembers the core of the SDK once it loaded. A failure is not remembered: the core
+// is probed again by the next check, as it may have failed for lack of memory or time.
+type coreCheck struct {
+	mu     sync.Mutex
+	loaded bool
+}
+
+// checkSDKCore fails when the SDK cannot load its core. The core is a WebAssembly module
+// embedded in the SDK: failing to load it otherwise surfaces as an obscure error, or a panic,
+// when signing in.
+func checkSDKCore(ctx context.Context) error {
+	return sdkCore.check(ctx, clientFactory)
+}
+
+// check probes the core with newClient until it loads, within coreProbeTimeout of ctx.
+func (c *coreCheck) check(ctx context.Context, newClient func(context.Context, ...onepassword.ClientOption) (*onepassword.Client, error)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.loaded {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, coreProbeTimeout)
+	defer cancel()
+	if err := probeCore(ctx, newClient); err != nil {
+		return err
+	}
+	c.loaded = true
+	return nil
+}
+
+// probeCore creates a client without a token with newClient: once the core is loaded, the SDK
+// rejects the missing token without calling 1Password, so any other error is the core failing.
+func probeCore(ctx context.Context, newClient func(context.Context, ...onepassword.ClientOption) (*onepassword.Client, error)) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf(errSDKCore, sdkVersion(), r)
+		}
+	}()
+	_, err = newClient(ctx, onepassword.WithIntegrationInfo(defaultIntegrationName, develBuildVersion))
+	if err == nil || strings.HasPrefix(err.Error(), sdkInitError) {
+		return nil
+	}
+	return fmt.Errorf(errSDKCore, sdkVersion(), err)
+}
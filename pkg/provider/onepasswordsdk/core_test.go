@@ -0,0 +1,91 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/1password/onepassword-sdk-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProbeCore(t *testing.T) {
+	tests := []struct {
+		name      string
+		newClient func(context.Context, ...onepassword.ClientOption) (*onepassword.Client, error)
+		wantErr   string
+	}{
+		{
+			name: "core loaded",
+			newClient: func(context.Context, ...onepassword.ClientOption) (*onepassword.Client, error) {
+				return nil, errors.New("error initializing client: invalid user input: service account token was not specified")
+			},
+		},
+		{
+			name: "core failing",
+			newClient: func(context.Context, ...onepassword.ClientOption) (*onepassword.Client, error) {
+				return nil, errors.New("failed to compile module: out of memory")
+			},
+			wantErr: "could not load its core, which it needs to sign in with a service account token: failed to compile module: out of memory",
+		},
+		{
+			name: "core panicking",
+			newClient: func(context.Context, ...onepassword.ClientOption) (*onepassword.Client, error) {
+				panic("wasm: unreachable")
+			},
+			wantErr: "could not load its core, which it needs to sign in with a service account token: wasm: unreachable",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := probeCore(context.Background(), tt.newClient)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+			assert.ErrorContains(t, err, "the 1Password SDK "+sdkVersion())
+		})
+	}
+}
+
+func TestCheckSDKCore(t *testing.T) {
+	// the SDK built in loads its core
+	assert.NoError(t, checkSDKCore(context.Background()))
+}
+
+func TestCoreCheck(t *testing.T) {
+	var (
+		check coreCheck
+		calls int
+	)
+	newClient := func(ctx context.Context, _ ...onepassword.ClientOption) (*onepassword.Client, error) {
+		calls++
+		_, ok := ctx.Deadline()
+		assert.True(t, ok, "the probe must be bounded")
+		if calls == 1 {
+			return nil, errors.New("failed to compile module: out of memory")
+		}
+		return nil, errors.New("error initializing client: invalid user input: service account token was not specified")
+	}
+
+	// a failure is probed again, a success is not
+	assert.ErrorContains(t, check.check(context.Background(), newClient), "out of memory")
+	assert.NoError(t, check.check(context.Background(), newClient))
+	assert.NoError(t, check.check(context.Background(), newClient))
+	assert.Equal(t, 2, calls)
+}
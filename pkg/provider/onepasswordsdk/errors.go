@@ -0,0 +1,127 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"errors"
+	"strings"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+)
+
+var (
+	// ErrSecretNotFound is matched by errors about a missing item or field. It also matches
+	// esv1beta1.NoSecretErr, so that the deletionPolicy of ExternalSecrets applies.
+	ErrSecretNotFound = errors.New("1Password secret not found")
+	// ErrVaultNotFound is matched by errors about a vault that does not exist or that the
+	// service account cannot access. Unlike ErrSecretNotFound, it does not match
+	// esv1beta1.NoSecretErr: a vault going missing must not delete the keys read from it.
+	ErrVaultNotFound = errors.New("1Password Vault not found")
+	// ErrPermissionDenied is matched by errors about the service account lacking a permission.
+	ErrPermissionDenied = errors.New("1Password permission denied")
+	// ErrConflict is matched by errors about an item modified since the version a PushSecret
+	// expected it at.
+	ErrConflict = errors.New("1Password Item was modified concurrently")
+	// ErrDeletionProtected is matched by errors about deleting an item tagged with the
+	// deletionProtectionTag of the store.
+	ErrDeletionProtected = errors.New("1Password Item is protected from deletion")
+	// ErrTokenRevoked is matched by errors about 1Password rejecting the token of the store,
+	// revoked, expired or invalid, which needs rotating. It also matches ErrPermissionDenied,
+	// which such errors were matched by before.
+	ErrTokenRevoked = errors.New("1Password token revoked")
+	// ErrCircuitOpen is matched by errors about a call failed fast by the circuit breaker of the
+	// store, open for 1Password being unavailable.
+	ErrCircuitOpen = errors.New("1Password circuit breaker open")
+)
+
+// notFoundErrors, vaultNotFoundErrors and permissionErrors are matched by mapError. Only a missing
+// item or field is ErrSecretNotFound, which the controller deletes keys for under the Delete and
+// Merge deletionPolicies: a message merely saying "not found" is left untyped, as it may be about
+// anything else.
+var (
+	notFoundErrors = []string{
+		"no item matched",
+		"no section matched",
+		"isn't a field",
+		"item not found",
+		"field not found",
+	}
+	vaultNotFoundErrors = []string{
+		"no vault matched",
+		"vault not found",
+	}
+	permissionErrors = []string{
+		"permission",
+		"forbidden",
+		"unauthorized",
+		"access denied",
+		"not authorized",
+	}
+)
+
+// typedError is an error matching kind with errors.Is while keeping the message of err.
+type typedError struct {
+	kind error
+	err  error
+}
+
+func newTypedError(kind, err error) error {
+	return &typedError{kind: kind, err: err}
+}
+
+func (e *typedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *typedError) Unwrap() []error {
+	switch e.kind {
+	case ErrSecretNotFound:
+		return []error{e.kind, esv1beta1.NoSecretErr, e.err}
+	case ErrTokenRevoked:
+		return []error{e.kind, ErrPermissionDenied, e.err}
+	}
+	return []error{e.kind, e.err}
+}
+
+// mapError types err after what it is about, leaving already typed errors untouched. The errors of
+// the SDK come out of its WASM core as plain strings and cannot be inspected any other way, so
+// they are matched by message. Every SecretsClient method maps the errors it returns with it. The token being rejected is
+// spelled out in the message, so that it stands out in the status of the SecretStore.
+func mapError(err error) error {
+	var typed *typedError
+	if err == nil || errors.As(err, &typed) {
+		return err
+	}
+	if isAuthError(err) {
+		return tokenRejected(err)
+	}
+	msg := strings.ToLower(err.Error())
+	for _, permission := range permissionErrors {
+		if strings.Contains(msg, permission) {
+			return newTypedError(ErrPermissionDenied, err)
+		}
+	}
+	for _, notFound := range vaultNotFoundErrors {
+		if strings.Contains(msg, notFound) {
+			return newTypedError(ErrVaultNotFound, err)
+		}
+	}
+	for _, notFound := range notFoundErrors {
+		if strings.Contains(msg, notFound) {
+			return newTypedError(ErrSecretNotFound, err)
+		}
+	}
+	return err
+}
@@ -0,0 +1,146 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	"github.com/external-secrets/external-secrets/pkg/provider/onepasswordsdk/fake"
+)
+
+func TestMapError(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantMsg string
+		wantIs  []error
+		wantNot []error
+	}{
+		{
+			name:    "nil",
+			err:     nil,
+			wantNot: []error{ErrSecretNotFound, ErrVaultNotFound, ErrPermissionDenied},
+		},
+		{
+			name:    "sdk not found",
+			err:     fake.ErrNotFound,
+			wantIs:  []error{ErrSecretNotFound, esv1beta1.NoSecretErr, fake.ErrNotFound},
+			wantNot: []error{ErrPermissionDenied},
+		},
+		{
+			name:    "sdk vault not found",
+			err:     errors.New("no vault matched the secret reference query"),
+			wantIs:  []error{ErrVaultNotFound},
+			wantNot: []error{ErrSecretNotFound, esv1beta1.NoSecretErr},
+		},
+		{
+			name:    "bare not found is left alone",
+			err:     errors.New("endpoint not found"),
+			wantNot: []error{ErrSecretNotFound, ErrVaultNotFound, esv1beta1.NoSecretErr},
+		},
+		{
+			name:    "sdk permission denied",
+			err:     errors.New("Forbidden: the service account does not have permission to read the vault"),
+			wantIs:  []error{ErrPermissionDenied},
+			wantNot: []error{ErrSecretNotFound, esv1beta1.NoSecretErr},
+		},
+		{
+			name:    "sdk token revoked",
+			err:     errors.New("Unauthorized: the service account token was revoked"),
+			wantMsg: "1Password rejected the service account token: Unauthorized: the service account token was revoked",
+			wantIs:  []error{ErrTokenRevoked, ErrPermissionDenied},
+			wantNot: []error{ErrSecretNotFound, esv1beta1.NoSecretErr},
+		},
+		{
+			name:    "typed errors are left alone",
+			err:     newTypedError(ErrVaultNotFound, errors.New("1Password Vault \"v\" not found")),
+			wantIs:  []error{ErrVaultNotFound},
+			wantNot: []error{ErrSecretNotFound, esv1beta1.NoSecretErr},
+		},
+		{
+			name:    "other errors are left alone",
+			err:     errors.New("internal server error"),
+			wantNot: []error{ErrSecretNotFound, ErrVaultNotFound, ErrPermissionDenied},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mapError(tt.err)
+			switch {
+			case tt.err == nil:
+				assert.NoError(t, got)
+			case tt.wantMsg != "":
+				assert.EqualError(t, got, tt.wantMsg)
+			default:
+				assert.EqualError(t, got, tt.err.Error())
+			}
+			for _, target := range tt.wantIs {
+				assert.ErrorIs(t, got, target)
+			}
+			for _, target := range tt.wantNot {
+				assert.NotErrorIs(t, got, target)
+			}
+		})
+	}
+}
+
+func TestTypedErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		ref     esv1beta1.ExternalSecretDataRemoteRef
+		vaults  []string
+		wantIs  error
+		wantErr string
+	}{
+		{
+			name:    "missing vault",
+			ref:     esv1beta1.ExternalSecretDataRemoteRef{Key: "op://missing-vault/my-item", Property: key1},
+			wantIs:  ErrVaultNotFound,
+			wantErr: `1Password Vault "missing-vault" not found`,
+		},
+		{
+			name:    "missing item",
+			ref:     esv1beta1.ExternalSecretDataRemoteRef{Key: "op://my-vault/missing-item", Property: key1},
+			wantIs:  esv1beta1.NoSecretErr,
+			wantErr: `1Password Item "missing-item" not found in Vault "my-vault"`,
+		},
+		{
+			name:    "missing field",
+			ref:     esv1beta1.ExternalSecretDataRemoteRef{Key: "op://my-vault/my-item", Property: "missing"},
+			wantIs:  ErrSecretNotFound,
+			wantErr: `1Password ItemField "missing" not found in Item "my-item"`,
+		},
+		{
+			name:    "vault outside the allow-list",
+			ref:     esv1beta1.ExternalSecretDataRemoteRef{Key: "op://my-vault/my-item", Property: key1},
+			vaults:  []string{"other-vault"},
+			wantIs:  ErrPermissionDenied,
+			wantErr: `1Password Vault "my-vault" is not allowed`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &ProviderOnePasswordSdk{client: newFakeClient().SDKClient(), vaults: tt.vaults}
+			_, err := provider.GetSecret(context.Background(), tt.ref)
+			assert.ErrorIs(t, err, tt.wantIs)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,321 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/1password/onepassword-sdk-go"
+)
+
+const opPrefix = "op://"
+
+// Method names accepted by WithError.
+const (
+	SecretsResolve = "Secrets.Resolve"
+	ItemsCreate    = "Items.Create"
+	ItemsGet       = "Items.Get"
+	ItemsPut       = "Items.Put"
+	ItemsDelete    = "Items.Delete"
+	ItemsListAll   = "Items.ListAll"
+	VaultsListAll  = "Vaults.ListAll"
+)
+
+var (
+	// ErrNotFound mimics the error returned by the SDK when a lookup does not match anything.
+	ErrNotFound = errors.New("no item matched the secret reference query")
+	// ErrMoreThanOneField mimics the error returned by the SDK when a field label is ambiguous.
+	ErrMoreThanOneField = errors.New("more than one field matched the secret reference")
+)
+
+// Client is an in-memory fake of the 1Password SDK. Vaults and items must be preloaded.
+type Client struct {
+	MockVaults      []onepassword.VaultOverview
+	MockItems       map[string][]onepassword.Item // keyed by vault ID
+	MockErrors      map[string]error              // keyed by method name
+	MockErrorCounts map[string]int                // remaining failures, keyed by method name
+	Calls           map[string]int                // keyed by method name
+	MockVaultErrors map[string]error              // returned by Items.ListAll, keyed by vault ID
+	MockItemErrors  map[string]error              // returned by Items.Get, keyed by item ID
+
+	mu sync.Mutex // guards Calls and MockErrorCounts, which concurrent reads update
+}
+
+// NewClient returns an empty fake client.
+func NewClient() *Client {
+	return &Client{
+		MockItems:       map[string][]onepassword.Item{},
+		MockErrors:      map[string]error{},
+		MockErrorCounts: map[string]int{},
+		Calls:           map[string]int{},
+		MockVaultErrors: map[string]error{},
+		MockItemErrors:  map[string]error{},
+	}
+}
+
+// WithError makes every call to method return err.
+func (c *Client) WithError(method string, err error) *Client {
+	c.MockErrors[method] = err
+	return c
+}
+
+// WithErrorTimes makes the next n calls to method return err.
+func (c *Client) WithErrorTimes(method string, err error, n int) *Client {
+	c.MockErrors[method] = err
+	c.MockErrorCounts[method] = n
+	return c
+}
+
+// WithVaultError makes listing the items of the vault return err.
+func (c *Client) WithVaultError(vaultID string, err error) *Client {
+	c.MockVaultErrors[vaultID] = err
+	return c
+}
+
+// WithItemError makes getting the item return err.
+func (c *Client) WithItemError(itemID string, err error) *Client {
+	c.MockItemErrors[itemID] = err
+	return c
+}
+
+// err records a call to method and returns the error it is mocked to fail with, if any.
+func (c *Client) err(method string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Calls[method]++
+	err := c.MockErrors[method]
+	if err == nil {
+		return nil
+	}
+	if n, ok := c.MockErrorCounts[method]; ok {
+		if n == 0 {
+			return nil
+		}
+		c.MockErrorCounts[method] = n - 1
+	}
+	return err
+}
+
+// AddVault preloads a vault.
+func (c *Client) AddVault(id, title string) *Client {
+	c.MockVaults = append(c.MockVaults, onepassword.VaultOverview{ID: id, Title: title})
+	return c
+}
+
+// AddItem preloads an item into the vault referenced by item.VaultID.
+func (c *Client) AddItem(item onepassword.Item) *Client {
+	c.MockItems[item.VaultID] = append(c.MockItems[item.VaultID], item)
+	return c
+}
+
+// SDKClient returns an onepassword.Client whose APIs are served by the fake.
+func (c *Client) SDKClient() onepassword.Client {
+	return onepassword.Client{
+		Secrets: &secretsAPI{c},
+		Items:   &itemsAPI{c},
+		Vaults:  &vaultsAPI{c},
+	}
+}
+
+func (c *Client) vault(nameOrID string) (*onepassword.VaultOverview, bool) {
+	for i := range c.MockVaults {
+		if c.MockVaults[i].ID == nameOrID || c.MockVaults[i].Title == nameOrID {
+			return &c.MockVaults[i], true
+		}
+	}
+	return nil, false
+}
+
+func (c *Client) item(vaultID, nameOrID string) (*onepassword.Item, bool) {
+	items := c.MockItems[vaultID]
+	for i := range items {
+		if items[i].ID == nameOrID || items[i].Title == nameOrID {
+			return &items[i], true
+		}
+	}
+	return nil, false
+}
+
+type secretsAPI struct {
+	c *Client
+}
+
+// Resolve resolves op://vault/item/[section/]field against the preloaded items.
+func (s *secretsAPI) Resolve(_ context.Context, secretReference string) (string, error) {
+	if err := s.c.err(SecretsResolve); err != nil {
+		return "", err
+	}
+	parts := strings.Split(strings.TrimPrefix(secretReference, opPrefix), "/")
+	if !strings.HasPrefix(secretReference, opPrefix) || len(parts) < 3 || len(parts) > 4 {
+		return "", fmt.Errorf("invalid secret reference: %s", secretReference)
+	}
+	vault, ok := s.c.vault(parts[0])
+	if !ok {
+		return "", ErrNotFound
+	}
+	item, ok := s.c.item(vault.ID, parts[1])
+	if !ok {
+		return "", ErrNotFound
+	}
+	section := ""
+	if len(parts) == 4 {
+		section = parts[2]
+	}
+	fieldName := parts[len(parts)-1]
+	var values []string
+	for _, field := range item.Fields {
+		if field.Title != fieldName && field.ID != fieldName {
+			continue
+		}
+		if section != "" && !inSection(item, field, section) {
+			continue
+		}
+		values = append(values, field.Value)
+	}
+	switch len(values) {
+	case 0:
+		return "", ErrNotFound
+	case 1:
+		return values[0], nil
+	default:
+		return "", ErrMoreThanOneField
+	}
+}
+
+func inSection(item *onepassword.Item, field onepassword.ItemField, section string) bool {
+	if field.SectionID == nil {
+		return false
+	}
+	for _, s := range item.Sections {
+		if s.ID == *field.SectionID && (s.Title == section || s.ID == section) {
+			return true
+		}
+	}
+	return false
+}
+
+type itemsAPI struct {
+	c *Client
+}
+
+// Create stores a new item and assigns it an ID derived from its title.
+func (i *itemsAPI) Create(_ context.Context, params onepassword.ItemCreateParams) (onepassword.Item, error) {
+	if err := i.c.err(ItemsCreate); err != nil {
+		return onepassword.Item{}, err
+	}
+	item := onepassword.Item{
+		ID:       params.Title + "-id",
+		Title:    params.Title,
+		Category: params.Category,
+		VaultID:  params.VaultID,
+		Fields:   params.Fields,
+		Sections: params.Sections,
+		Tags:     params.Tags,
+		Version:  1,
+	}
+	i.c.AddItem(item)
+	return copyItem(item), nil
+}
+
+// Get returns a copy of a preloaded item.
+func (i *itemsAPI) Get(_ context.Context, vaultID, itemID string) (onepassword.Item, error) {
+	if err := i.c.err(ItemsGet); err != nil {
+		return onepassword.Item{}, err
+	}
+	if err := i.c.MockItemErrors[itemID]; err != nil {
+		return onepassword.Item{}, err
+	}
+	for _, item := range i.c.MockItems[vaultID] {
+		if item.ID == itemID {
+			return copyItem(item), nil
+		}
+	}
+	return onepassword.Item{}, ErrNotFound
+}
+
+// Put replaces a preloaded item and bumps its version.
+func (i *itemsAPI) Put(_ context.Context, item onepassword.Item) (onepassword.Item, error) {
+	if err := i.c.err(ItemsPut); err != nil {
+		return onepassword.Item{}, err
+	}
+	items := i.c.MockItems[item.VaultID]
+	for idx := range items {
+		if items[idx].ID == item.ID {
+			item.Version = items[idx].Version + 1
+			items[idx] = copyItem(item)
+			return copyItem(item), nil
+		}
+	}
+	return onepassword.Item{}, ErrNotFound
+}
+
+// Delete removes a preloaded item.
+func (i *itemsAPI) Delete(_ context.Context, vaultID, itemID string) error {
+	if err := i.c.err(ItemsDelete); err != nil {
+		return err
+	}
+	items := i.c.MockItems[vaultID]
+	for idx := range items {
+		if items[idx].ID == itemID {
+			i.c.MockItems[vaultID] = append(items[:idx], items[idx+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// ListAll returns an overview of every item in a vault.
+func (i *itemsAPI) ListAll(_ context.Context, vaultID string) (*onepassword.Iterator[onepassword.ItemOverview], error) {
+	if err := i.c.err(ItemsListAll); err != nil {
+		return nil, err
+	}
+	if err := i.c.MockVaultErrors[vaultID]; err != nil {
+		return nil, err
+	}
+	overviews := make([]onepassword.ItemOverview, 0, len(i.c.MockItems[vaultID]))
+	for _, item := range i.c.MockItems[vaultID] {
+		overviews = append(overviews, onepassword.ItemOverview{
+			ID:       item.ID,
+			Title:    item.Title,
+			Category: item.Category,
+			VaultID:  item.VaultID,
+		})
+	}
+	return onepassword.NewIterator(overviews), nil
+}
+
+type vaultsAPI struct {
+	c *Client
+}
+
+// ListAll returns every preloaded vault.
+func (v *vaultsAPI) ListAll(_ context.Context) (*onepassword.Iterator[onepassword.VaultOverview], error) {
+	if err := v.c.err(VaultsListAll); err != nil {
+		return nil, err
+	}
+	return onepassword.NewIterator(slices.Clone(v.c.MockVaults)), nil
+}
+
+func copyItem(item onepassword.Item) onepassword.Item {
+	item.Fields = slices.Clone(item.Fields)
+	item.Sections = slices.Clone(item.Sections)
+	item.Tags = slices.Clone(item.Tags)
+	return item
+}
@@ -0,0 +1,408 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/1password/onepassword-sdk-go"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	"github.com/external-secrets/external-secrets/pkg/find"
+	"github.com/external-secrets/external-secrets/pkg/utils"
+)
+
+const (
+	errFindFilterRequired = "one of 'find.path', 'find.name' or 'find.tags' must be set to sync 1Password Items in bulk"
+	errMarshalItem        = "error marshaling 1Password Item %q: %w"
+	errTooManyItems       = "more than %d 1Password Items matched, narrow down find or raise spec.provider.onepasswordsdk.maxItems"
+	errFindCategory       = "unsupported 1Password Item category %q in find.tags.category, expected one of: %v"
+
+	// defaultGetAllSecretsConcurrency bounds the number of items GetAllSecrets gets at once when
+	// getAllSecretsConcurrency is unset.
+	defaultGetAllSecretsConcurrency = 4
+
+	// categoryTag is the find.tags key selecting items by category rather than by tag.
+	categoryTag = "category"
+
+	// tagSeparator joins a find.tags key and value into a nested 1Password tag, e.g. env/prod.
+	tagSeparator = "/"
+	// vaultSeparator joins the vault and item title of items whose titles collide across vaults,
+	// and the key and item ID of items whose keys collide once converted.
+	vaultSeparator = "_"
+)
+
+// findCategories are the item categories find.tags.category accepts.
+var findCategories = []onepassword.ItemCategory{
+	onepassword.ItemCategoryLogin,
+	onepassword.ItemCategorySecureNote,
+	onepassword.ItemCategoryCreditCard,
+	onepassword.ItemCategoryCryptoWallet,
+	onepassword.ItemCategoryIdentity,
+	onepassword.ItemCategoryPassword,
+	onepassword.ItemCategoryDocument,
+	onepassword.ItemCategoryAPICredentials,
+	onepassword.ItemCategoryBankAccount,
+	onepassword.ItemCategoryDatabase,
+	onepassword.ItemCategoryDriverLicense,
+	onepassword.ItemCategoryEmail,
+	onepassword.ItemCategoryMedicalRecord,
+	onepassword.ItemCategoryMembership,
+	onepassword.ItemCategoryOutdoorLicense,
+	onepassword.ItemCategoryPassport,
+	onepassword.ItemCategoryRewards,
+	onepassword.ItemCategoryRouter,
+	onepassword.ItemCategoryServer,
+	onepassword.ItemCategorySSHKey,
+	onepassword.ItemCategorySocialSecurityNumber,
+	onepassword.ItemCategorySoftwareLicense,
+	onepassword.ItemCategoryPerson,
+}
+
+// foundItem is an item matched by GetAllSecrets together with the vault it lives in.
+type foundItem struct {
+	vault onepassword.VaultOverview
+	item  onepassword.Item
+}
+
+// GetAllSecrets syncs every 1Password Item whose title matches find.name and whose tags
+// match find.tags into a single map keyed by item title. When find.path is set, only the
+// vault with that title or ID is searched. Each value is the JSON encoded field
+// map of the item. Items whose titles collide across vaults are keyed by <vault>_<title> instead.
+// The find.tags key category is reserved: it selects the items of that category, such as
+// ApiCredentials, rather than the items tagged category/<value>.
+// Keys are converted with find.conversionStrategy, appending the item ID to keys that collide.
+// With continueOnError, vaults and items that cannot be read are logged and skipped, failing
+// only when nothing could be read at all. With maxItems, it fails as soon as more items match.
+// The matching items of a vault are got getAllSecretsConcurrency at a time; the errors of those
+// that fail are joined, and no further item is got once one fails or ctx is done.
+// Archived items are never synced: the SDK only lists active items and has no item state to
+// include archived ones with.
+func (provider *ProviderOnePasswordSdk) GetAllSecrets(ctx context.Context, ref esv1beta1.ExternalSecretFind) (map[string][]byte, error) {
+	if err := provider.checkReadable(); err != nil {
+		return nil, err
+	}
+	ctx = withOperation(ctx, "GetAllSecrets")
+	ctx, cancel := provider.withTimeout(ctx)
+	defer cancel()
+	secretMap, err := reauth(ctx, provider, func() (map[string][]byte, error) {
+		return provider.getAllSecrets(ctx, ref)
+	})
+	if err != nil {
+		return nil, provider.redact.err(mapError(err))
+	}
+	return secretMap, nil
+}
+
+func (provider *ProviderOnePasswordSdk) getAllSecrets(ctx context.Context, ref esv1beta1.ExternalSecretFind) (map[string][]byte, error) {
+	if len(ref.Tags) == 0 && ref.Name == nil && ref.Path == nil {
+		return nil, errors.New(errFindFilterRequired)
+	}
+	tags, category, err := splitCategoryTag(ref.Tags)
+	if err != nil {
+		return nil, err
+	}
+	// compile the regexp up front so an invalid expression fails before any API call
+	var matcher *find.Matcher
+	if ref.Name != nil {
+		m, err := find.New(*ref.Name)
+		if err != nil {
+			return nil, err
+		}
+		matcher = m
+	}
+
+	vaults, err := provider.findVaults(ctx, ref.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		found   []foundItem
+		skipped []error
+	)
+	// skip reports whether err, met reading a single vault or item, leaves it out of the result
+	// rather than failing the whole call. Concurrent item reads call it holding a lock.
+	skip := func(err error, vault onepassword.VaultOverview) bool {
+		if !provider.continueOnError || !isSkippable(err) {
+			return false
+		}
+		loggerFrom(ctx).Info("skipping unreadable 1Password secret", "vault", provider.redact.name(vault.Title), "error", provider.redact.message(err.Error()))
+		skipped = append(skipped, err)
+		return true
+	}
+	for _, vault := range vaults {
+		items, err := provider.client.Items.ListAll(ctx, vault.ID)
+		if err != nil {
+			err = fmt.Errorf(errListItems, err)
+			if skip(err, vault) {
+				continue
+			}
+			return nil, err
+		}
+		// items are filtered on their overview first and only matching ones are fetched in full
+		var matching []onepassword.ItemOverview
+		err = forEach(items, func(overview *onepassword.ItemOverview) error {
+			if matcher != nil && !matcher.MatchName(overview.Title) {
+				return nil
+			}
+			if category != "" && overview.Category != category {
+				return nil
+			}
+			matching = append(matching, *overview)
+			return nil
+		})
+		if err != nil {
+			if skip(err, vault) {
+				continue
+			}
+			return nil, err
+		}
+		found, err = provider.getItems(ctx, vault, matching, tags, found, skip)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(found) == 0 && len(skipped) > 0 {
+		return nil, errors.Join(skipped...)
+	}
+
+	return foundItemsToMap(found, ref.ConversionStrategy)
+}
+
+// getItems gets the overviews of vault in full, getAllSecretsConcurrency at a time, and appends
+// the items matching tags to found, in the order they were listed. Overviews carry no tags, so
+// the full item is needed to filter on them. The errors of the items that cannot be got, and are
+// not skipped, are joined; once one fails, or ctx is done, no further item is got.
+func (provider *ProviderOnePasswordSdk) getItems(ctx context.Context, vault onepassword.VaultOverview, overviews []onepassword.ItemOverview, tags map[string]string, found []foundItem, skip func(error, onepassword.VaultOverview) bool) ([]foundItem, error) {
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	concurrency := provider.concurrency
+	if concurrency <= 0 {
+		concurrency = defaultGetAllSecretsConcurrency
+	}
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		sem   = make(chan struct{}, concurrency)
+		items = make([]*onepassword.Item, len(overviews))
+		count = len(found)
+		errs  []error
+	)
+	for i := range overviews {
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			item, err := provider.client.Items.Get(ctx, vault.ID, overviews[i].ID)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				// the items canceled by an earlier failure add nothing to it
+				if len(errs) > 0 && isContextError(err) {
+					return
+				}
+				if err = fmt.Errorf(errGetItem, err); !skip(err, vault) {
+					errs = append(errs, err)
+					cancel()
+				}
+				return
+			}
+			if !matchesTags(item.Tags, tags) {
+				return
+			}
+			count++
+			if provider.maxItems > 0 && count > provider.maxItems {
+				if count == provider.maxItems+1 {
+					errs = append(errs, fmt.Errorf(errTooManyItems, provider.maxItems))
+				}
+				cancel()
+				return
+			}
+			items[i] = &item
+		}(i)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	if err := parent.Err(); err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if item != nil {
+			found = append(found, foundItem{vault: vault, item: *item})
+		}
+	}
+	return found, nil
+}
+
+// isSkippable reports whether err is left out of the result of GetAllSecrets with continueOnError:
+// missing permissions on a vault or item, or a transient error.
+func isSkippable(err error) bool {
+	return isTransient(err) || errors.Is(mapError(err), ErrPermissionDenied)
+}
+
+// findVaults returns the vault named by path, or every allowed vault the token can access when
+// path is nil.
+func (provider *ProviderOnePasswordSdk) findVaults(ctx context.Context, path *string) ([]onepassword.VaultOverview, error) {
+	if path != nil {
+		vault, err := provider.findVault(ctx, *path)
+		if err != nil {
+			return nil, err
+		}
+		return []onepassword.VaultOverview{*vault}, nil
+	}
+
+	all, err := provider.listVaults(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var vaults []onepassword.VaultOverview
+	for i := range all {
+		if provider.vaultAllowed(&all[i]) {
+			vaults = append(vaults, all[i])
+		}
+	}
+	return vaults, nil
+}
+
+// foundItemsToMap keys every item by title, prefixing the vault title on collisions. Keys are
+// converted with the conversionStrategy of find, as the controller does next: items whose keys
+// only collide once converted are told apart by appending their ID, so that the controller does
+// not fail on the collision.
+func foundItemsToMap(found []foundItem, strategy esv1beta1.ExternalSecretConversionStrategy) (map[string][]byte, error) {
+	titles := make(map[string]int, len(found))
+	for _, f := range found {
+		titles[f.item.Title]++
+	}
+	keys := make([]string, len(found))
+	converted := make(map[string]int, len(found))
+	for i, f := range found {
+		key := f.item.Title
+		if titles[key] > 1 {
+			key = f.vault.Title + vaultSeparator + key
+		}
+		keys[i] = convertKey(strategy, key)
+		converted[keys[i]]++
+	}
+
+	secretData := make(map[string][]byte, len(found))
+	for i, f := range found {
+		key := keys[i]
+		if converted[key] > 1 {
+			key += vaultSeparator + f.item.ID
+		}
+		fields, err := itemFieldsToMap(&f.item, nil)
+		if err != nil {
+			return nil, err
+		}
+		value, err := marshalFields(fields)
+		if err != nil {
+			return nil, fmt.Errorf(errMarshalItem, f.item.Title, err)
+		}
+		secretData[key] = value
+	}
+
+	return secretData, nil
+}
+
+// convertKey applies strategy to key like the controller does to the keys of dataFrom.find.
+func convertKey(strategy esv1beta1.ExternalSecretConversionStrategy, key string) string {
+	// a single key cannot collide, so converting it cannot fail
+	converted, _ := utils.ConvertKeys(strategy, map[string][]byte{key: nil})
+	for k := range converted {
+		return k
+	}
+	return key
+}
+
+// marshalFields encodes a field map as a JSON object of strings.
+func marshalFields(fields map[string][]byte) ([]byte, error) {
+	out := make(map[string]string, len(fields))
+	for k, v := range fields {
+		out[k] = string(v)
+	}
+	return json.Marshal(out)
+}
+
+// splitCategoryTag takes the category key out of find.tags, validating its value.
+func splitCategoryTag(tags map[string]string) (map[string]string, onepassword.ItemCategory, error) {
+	value, ok := tags[categoryTag]
+	if !ok {
+		return tags, "", nil
+	}
+	category := onepassword.ItemCategory(value)
+	if !slices.Contains(findCategories, category) {
+		return nil, "", fmt.Errorf(errFindCategory, value, findCategories)
+	}
+	rest := make(map[string]string, len(tags)-1)
+	for k, v := range tags {
+		if k != categoryTag {
+			rest[k] = v
+		}
+	}
+	return rest, category, nil
+}
+
+// matchesTags reports whether every key/value pair is present in tags. A pair matches the
+// nested tag <key>/<value>, or the plain tag <key> when the value is empty.
+func matchesTags(tags []string, want map[string]string) bool {
+	for k, v := range want {
+		tag := k
+		if v != "" {
+			tag = k + tagSeparator + v
+		}
+		if !slices.Contains(tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// forEach calls fn for every element of it, stopping at the first error.
+func forEach[T any](it *onepassword.Iterator[T], fn func(*T) error) error {
+	for {
+		v, err := it.Next()
+		if errors.Is(err, onepassword.ErrorIteratorDone) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(v); err != nil {
+			return err
+		}
+	}
+}
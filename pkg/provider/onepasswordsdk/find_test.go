@@ -0,0 +1,436 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/1password/onepassword-sdk-go"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/utils/ptr"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	"github.com/external-secrets/external-secrets/pkg/provider/onepasswordsdk/fake"
+	"github.com/external-secrets/external-secrets/pkg/utils"
+)
+
+const (
+	otherVault, otherVaultID = "other-vault", "other-vault-id"
+	tagProd, tagTeam         = "env/prod", "team"
+)
+
+func newFindClient() *fake.Client {
+	item := func(id, title, vaultID string, tags ...string) onepassword.Item {
+		return onepassword.Item{
+			ID:      id,
+			Title:   title,
+			VaultID: vaultID,
+			Tags:    tags,
+			Fields: []onepassword.ItemField{
+				{ID: "f1", Title: key1, FieldType: onepassword.ItemFieldTypeConcealed, Value: id},
+			},
+		}
+	}
+	return fake.NewClient().
+		AddVault(myVaultID, myVault).
+		AddVault(otherVaultID, otherVault).
+		AddItem(item("a", "alpha", myVaultID, tagProd, tagTeam)).
+		AddItem(item("b", "beta", myVaultID, tagProd)).
+		AddItem(item("c", "gamma", myVaultID)).
+		AddItem(item("d", "alpha", otherVaultID, tagProd, tagTeam))
+}
+
+func TestGetAllSecretsTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		ref     esv1beta1.ExternalSecretFind
+		want    map[string][]byte
+		wantErr string
+	}{
+		{
+			name:    "no filter",
+			ref:     esv1beta1.ExternalSecretFind{},
+			wantErr: errFindFilterRequired,
+		},
+		{
+			name: "nested tag",
+			ref:  esv1beta1.ExternalSecretFind{Tags: map[string]string{"env": "prod"}},
+			want: map[string][]byte{
+				"my-vault_alpha":    []byte(`{"key1":"a"}`),
+				"beta":              []byte(`{"key1":"b"}`),
+				"other-vault_alpha": []byte(`{"key1":"d"}`),
+			},
+		},
+		{
+			name: "all tags must match",
+			ref:  esv1beta1.ExternalSecretFind{Tags: map[string]string{"env": "prod", tagTeam: ""}},
+			want: map[string][]byte{
+				"my-vault_alpha":    []byte(`{"key1":"a"}`),
+				"other-vault_alpha": []byte(`{"key1":"d"}`),
+			},
+		},
+		{
+			name: "name regexp",
+			ref:  esv1beta1.ExternalSecretFind{Name: &esv1beta1.FindName{RegExp: "^(beta|gamma)$"}},
+			want: map[string][]byte{
+				"beta":  []byte(`{"key1":"b"}`),
+				"gamma": []byte(`{"key1":"c"}`),
+			},
+		},
+		{
+			name: "name regexp and tags",
+			ref: esv1beta1.ExternalSecretFind{
+				Name: &esv1beta1.FindName{RegExp: "^(beta|gamma)$"},
+				Tags: map[string]string{"env": "prod"},
+			},
+			want: map[string][]byte{
+				"beta": []byte(`{"key1":"b"}`),
+			},
+		},
+		{
+			name: "path by vault title",
+			ref:  esv1beta1.ExternalSecretFind{Path: ptr.To(otherVault)},
+			want: map[string][]byte{
+				"alpha": []byte(`{"key1":"d"}`),
+			},
+		},
+		{
+			name: "path by vault ID and tags",
+			ref:  esv1beta1.ExternalSecretFind{Path: ptr.To(myVaultID), Tags: map[string]string{"env": "prod"}},
+			want: map[string][]byte{
+				"alpha": []byte(`{"key1":"a"}`),
+				"beta":  []byte(`{"key1":"b"}`),
+			},
+		},
+		{
+			name:    "path vault not found",
+			ref:     esv1beta1.ExternalSecretFind{Path: ptr.To("missing")},
+			wantErr: `1Password Vault "missing" not found`,
+		},
+		{
+			name: "no match",
+			ref:  esv1beta1.ExternalSecretFind{Tags: map[string]string{"env": "dev"}},
+			want: map[string][]byte{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &ProviderOnePasswordSdk{client: newFindClient().SDKClient()}
+			got, err := provider.GetAllSecrets(context.Background(), tt.ref)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGetAllSecretsCategory(t *testing.T) {
+	newClient := func() *fake.Client {
+		return newFindClient().
+			AddItem(onepassword.Item{
+				ID: "e", Title: "epsilon", VaultID: myVaultID, Category: onepassword.ItemCategoryAPICredentials,
+				Fields: []onepassword.ItemField{{ID: "f1", Title: key1, Value: "e"}},
+			}).
+			AddItem(onepassword.Item{
+				ID: "f", Title: "zeta", VaultID: otherVaultID, Category: onepassword.ItemCategoryAPICredentials, Tags: []string{tagProd},
+				Fields: []onepassword.ItemField{{ID: "f1", Title: key1, Value: "f"}},
+			})
+	}
+	tests := []struct {
+		name     string
+		ref      esv1beta1.ExternalSecretFind
+		want     map[string][]byte
+		wantGets int
+		wantErr  string
+	}{
+		{
+			name: "category only",
+			ref:  esv1beta1.ExternalSecretFind{Tags: map[string]string{categoryTag: "ApiCredentials"}},
+			want: map[string][]byte{
+				"epsilon": []byte(`{"key1":"e"}`),
+				"zeta":    []byte(`{"key1":"f"}`),
+			},
+			wantGets: 2,
+		},
+		{
+			name: "category and tags",
+			ref:  esv1beta1.ExternalSecretFind{Tags: map[string]string{categoryTag: "ApiCredentials", "env": "prod"}},
+			want: map[string][]byte{
+				"zeta": []byte(`{"key1":"f"}`),
+			},
+			wantGets: 2,
+		},
+		{
+			name:    "unsupported category",
+			ref:     esv1beta1.ExternalSecretFind{Tags: map[string]string{categoryTag: "apiCredentials"}},
+			wantErr: `unsupported 1Password Item category "apiCredentials" in find.tags.category`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newClient()
+			provider := &ProviderOnePasswordSdk{client: client.SDKClient()}
+			got, err := provider.GetAllSecrets(context.Background(), tt.ref)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.Zero(t, client.Calls[fake.VaultsListAll])
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			// items of other categories are not fetched
+			assert.Equal(t, tt.wantGets, client.Calls[fake.ItemsGet])
+		})
+	}
+}
+
+func TestGetAllSecretsKeyConversion(t *testing.T) {
+	item := func(id, title string) onepassword.Item {
+		return onepassword.Item{
+			ID:      id,
+			Title:   title,
+			VaultID: myVaultID,
+			Fields:  []onepassword.ItemField{{ID: "f1", Title: key1, FieldType: onepassword.ItemFieldTypeConcealed, Value: id}},
+		}
+	}
+	client := fake.NewClient().
+		AddVault(myVaultID, myVault).
+		AddItem(item("a", "db password")).
+		AddItem(item("b", "db/password")).
+		AddItem(item("c", "api key"))
+	find := esv1beta1.ExternalSecretFind{Name: &esv1beta1.FindName{RegExp: ".*"}}
+	provider := &ProviderOnePasswordSdk{client: client.SDKClient()}
+
+	find.ConversionStrategy = esv1beta1.ExternalSecretConversionDefault
+	got, err := provider.GetAllSecrets(context.Background(), find)
+	assert.NoError(t, err)
+	want := map[string][]byte{
+		"db_password_a": []byte(`{"key1":"a"}`),
+		"db_password_b": []byte(`{"key1":"b"}`),
+		"api_key":       []byte(`{"key1":"c"}`),
+	}
+	assert.Equal(t, want, got)
+	// the controller converting the keys again leaves them as they are
+	converted, err := utils.ConvertKeys(find.ConversionStrategy, got)
+	assert.NoError(t, err)
+	assert.Equal(t, want, converted)
+
+	find.ConversionStrategy = esv1beta1.ExternalSecretConversionUnicode
+	got, err = provider.GetAllSecrets(context.Background(), find)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"db_U0020_password": []byte(`{"key1":"a"}`),
+		"db_U002f_password": []byte(`{"key1":"b"}`),
+		"api_U0020_key":     []byte(`{"key1":"c"}`),
+	}, got)
+}
+
+func TestGetAllSecretsContinueOnError(t *testing.T) {
+	errForbidden := errors.New("Forbidden: the service account does not have permission to read the vault")
+	errInvalid := errors.New("invalid item")
+	find := esv1beta1.ExternalSecretFind{Tags: map[string]string{"env": "prod"}}
+	tests := []struct {
+		name            string
+		client          *fake.Client
+		continueOnError bool
+		want            map[string][]byte
+		wantErr         string
+	}{
+		{
+			name:    "unreadable vault fails by default",
+			client:  newFindClient().WithVaultError(otherVaultID, errForbidden),
+			wantErr: "does not have permission",
+		},
+		{
+			name:            "unreadable vault is skipped",
+			client:          newFindClient().WithVaultError(otherVaultID, errForbidden),
+			continueOnError: true,
+			want: map[string][]byte{
+				"alpha": []byte(`{"key1":"a"}`),
+				"beta":  []byte(`{"key1":"b"}`),
+			},
+		},
+		{
+			name:            "unreadable item is skipped",
+			client:          newFindClient().WithItemError("b", errors.New("503 Service Unavailable")),
+			continueOnError: true,
+			want: map[string][]byte{
+				"my-vault_alpha":    []byte(`{"key1":"a"}`),
+				"other-vault_alpha": []byte(`{"key1":"d"}`),
+			},
+		},
+		{
+			name:            "other errors are not skipped",
+			client:          newFindClient().WithItemError("b", errInvalid),
+			continueOnError: true,
+			wantErr:         errInvalid.Error(),
+		},
+		{
+			name: "nothing readable fails",
+			client: newFindClient().
+				WithVaultError(myVaultID, errForbidden).
+				WithVaultError(otherVaultID, errForbidden),
+			continueOnError: true,
+			wantErr:         "does not have permission",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &ProviderOnePasswordSdk{client: tt.client.SDKClient(), continueOnError: tt.continueOnError}
+			got, err := provider.GetAllSecrets(context.Background(), find)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGetAllSecretsMaxItems(t *testing.T) {
+	find := esv1beta1.ExternalSecretFind{Tags: map[string]string{"env": "prod"}}
+
+	provider := &ProviderOnePasswordSdk{client: newFindClient().SDKClient(), maxItems: 3}
+	got, err := provider.GetAllSecrets(context.Background(), find)
+	assert.NoError(t, err)
+	assert.Len(t, got, 3)
+
+	client := newFindClient()
+	provider = &ProviderOnePasswordSdk{client: client.SDKClient(), maxItems: 1, concurrency: 1}
+	_, err = provider.GetAllSecrets(context.Background(), find)
+	assert.EqualError(t, err, "more than 1 1Password Items matched, narrow down find or raise spec.provider.onepasswordsdk.maxItems")
+	// getting items one at a time, listing stops at the first item over the limit
+	assert.Equal(t, 2, client.Calls[fake.ItemsGet])
+	assert.Equal(t, 1, client.Calls[fake.ItemsListAll])
+}
+
+// concurrentItems records the largest number of items got at once. The first calls are held
+// until hold of them run at once, so that the workers all start theirs.
+type concurrentItems struct {
+	onepassword.ItemsAPI
+	hold int
+
+	mu      sync.Mutex
+	running int
+	max     int
+	held    chan struct{}
+	release sync.Once
+}
+
+func newConcurrentItems(items onepassword.ItemsAPI, hold int) *concurrentItems {
+	return &concurrentItems{ItemsAPI: items, hold: hold, held: make(chan struct{})}
+}
+
+func (c *concurrentItems) Get(ctx context.Context, vaultID, itemID string) (onepassword.Item, error) {
+	c.mu.Lock()
+	c.running++
+	c.max = max(c.max, c.running)
+	if c.running >= c.hold {
+		c.release.Do(func() { close(c.held) })
+	}
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.running--
+		c.mu.Unlock()
+	}()
+	select {
+	case <-c.held:
+	case <-time.After(time.Second):
+		return onepassword.Item{}, fmt.Errorf("fewer than %d items got at once", c.hold)
+	}
+	return c.ItemsAPI.Get(ctx, vaultID, itemID)
+}
+
+func TestGetAllSecretsConcurrency(t *testing.T) {
+	find := esv1beta1.ExternalSecretFind{Path: ptr.To(myVault), Tags: map[string]string{"env": "prod"}}
+	newClient := func() *fake.Client {
+		client := fake.NewClient().AddVault(myVaultID, myVault)
+		for i := range 10 {
+			client.AddItem(onepassword.Item{
+				ID:      fmt.Sprintf("item-%d", i),
+				Title:   fmt.Sprintf("item-%d", i),
+				VaultID: myVaultID,
+				Tags:    []string{tagProd},
+			})
+		}
+		return client
+	}
+
+	tests := []struct {
+		name        string
+		concurrency int
+		want        int
+	}{
+		{name: "default", want: defaultGetAllSecretsConcurrency},
+		{name: "configured", concurrency: 2, want: 2},
+		{name: "sequential", concurrency: 1, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newClient().SDKClient()
+			items := newConcurrentItems(client.Items, tt.want)
+			client.Items = items
+			provider := &ProviderOnePasswordSdk{client: client, concurrency: tt.concurrency}
+			got, err := provider.GetAllSecrets(context.Background(), find)
+			assert.NoError(t, err)
+			assert.Len(t, got, 10)
+			assert.Equal(t, tt.want, items.max)
+		})
+	}
+
+	t.Run("errors are joined", func(t *testing.T) {
+		client := newClient().
+			WithItemError("item-0", errors.New("item-0 is broken")).
+			WithItemError("item-1", errors.New("item-1 is broken"))
+		// held, both items are got before either fails
+		sdkClient := client.SDKClient()
+		sdkClient.Items = newConcurrentItems(sdkClient.Items, 2)
+		provider := &ProviderOnePasswordSdk{client: sdkClient, concurrency: 2}
+		_, err := provider.GetAllSecrets(context.Background(), find)
+		assert.ErrorContains(t, err, "item-0 is broken")
+		assert.ErrorContains(t, err, "item-1 is broken")
+		// no further item is got once one fails
+		assert.Equal(t, 2, client.Calls[fake.ItemsGet])
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		client := newClient()
+		provider := &ProviderOnePasswordSdk{client: client.SDKClient()}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := provider.GetAllSecrets(ctx, find)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Zero(t, client.Calls[fake.ItemsGet])
+	})
+}
+
+func TestGetAllSecretsInvalidRegexp(t *testing.T) {
+	// the zero client panics on use, proving no API call is made before the regexp is compiled
+	provider := &ProviderOnePasswordSdk{}
+	_, err := provider.GetAllSecrets(context.Background(), esv1beta1.ExternalSecretFind{
+		Name: &esv1beta1.FindName{RegExp: "("},
+	})
+	assert.ErrorContains(t, err, "could not compile find.name.regexp")
+}
@@ -0,0 +1,119 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/1password/onepassword-sdk-go"
+)
+
+// jsonProperty is the property reading the whole item as a JSON document, unless the item has a
+// field labeled with it.
+const jsonProperty = "_json"
+
+// itemJSON is the JSON document of an item. Its sections, fields and tags are sorted, so that the
+// document only changes along with the item.
+type itemJSON struct {
+	ID       string            `json:"id"`
+	Title    string            `json:"title"`
+	Category string            `json:"category"`
+	Vault    vaultJSON         `json:"vault"`
+	Version  uint32            `json:"version"`
+	Tags     []string          `json:"tags"`
+	Sections []itemSectionJSON `json:"sections"`
+	Fields   []itemFieldJSON   `json:"fields"`
+	Notes    string            `json:"notes,omitempty"`
+}
+
+type vaultJSON struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+type itemSectionJSON struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+type itemFieldJSON struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Section string `json:"section,omitempty"`
+	Type    string `json:"type"`
+	Value   string `json:"value"`
+}
+
+// isJSONProperty reports whether property reads the whole item as JSON.
+func isJSONProperty(item *onepassword.Item, property string) bool {
+	if property != jsonProperty {
+		return false
+	}
+	_, err := itemFieldValue(item, "", property, "")
+	return errors.Is(err, ErrSecretNotFound)
+}
+
+// itemToJSON returns the item as a JSON document of its metadata, sections, fields and notes.
+// Concealed values are included like any other, as reading the item already grants them.
+// Sections and fields are sorted by ID, fields of the same ID by section.
+func (provider *ProviderOnePasswordSdk) itemToJSON(ctx context.Context, vault *onepassword.VaultOverview, item *onepassword.Item) ([]byte, error) {
+	doc := itemJSON{
+		ID:       item.ID,
+		Title:    item.Title,
+		Category: string(item.Category),
+		Vault:    vaultJSON{ID: vault.ID, Title: vault.Title},
+		Version:  item.Version,
+		Tags:     slices.Sorted(slices.Values(item.Tags)),
+		Sections: make([]itemSectionJSON, 0, len(item.Sections)),
+		Fields:   make([]itemFieldJSON, 0, len(item.Fields)),
+	}
+	if doc.Tags == nil {
+		doc.Tags = []string{}
+	}
+	for _, section := range item.Sections {
+		doc.Sections = append(doc.Sections, itemSectionJSON{ID: section.ID, Title: section.Title})
+	}
+	slices.SortFunc(doc.Sections, func(a, b itemSectionJSON) int { return cmp.Compare(a.ID, b.ID) })
+	for _, field := range item.Fields {
+		if field.FieldType == onepassword.ItemFieldTypeUnsupported {
+			continue
+		}
+		f := itemFieldJSON{ID: field.ID, Title: field.Title, Type: string(field.FieldType), Value: field.Value}
+		if field.SectionID != nil {
+			f.Section = *field.SectionID
+		}
+		doc.Fields = append(doc.Fields, f)
+	}
+	slices.SortFunc(doc.Fields, func(a, b itemFieldJSON) int {
+		return cmp.Or(cmp.Compare(a.ID, b.ID), cmp.Compare(a.Section, b.Section))
+	})
+
+	notes := map[string][]byte{}
+	if err := provider.addNotes(ctx, item, notes); err != nil {
+		return nil, err
+	}
+	doc.Notes = string(notes[notesPlain])
+
+	value, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf(errMarshalItem, item.Title, err)
+	}
+	return value, nil
+}
@@ -0,0 +1,162 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+)
+
+const (
+	redacted = "***"
+	// redactedPrefix and redactedHashLen make up a redacted name: enough of its hash to tell
+	// which name it is, given the name.
+	redactedPrefix  = "sha256:"
+	redactedHashLen = 8
+)
+
+// quotedPattern matches the Go-quoted strings in an error message, which is how errors of the
+// provider name vaults, items, sections and fields.
+var quotedPattern = regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)
+
+// namesListPattern matches the lists of names some errors suggest, such as the labels and IDs
+// of the fields of an item, which are not quoted. Pushed fields take their label as ID.
+var namesListPattern = regexp.MustCompile(`(available fields|available IDs|with one of): ([^;]*)`)
+
+const namesListSep = ", "
+
+type loggerKey struct{}
+
+// withOperation returns a context whose logger is tagged with the SecretsClient method being
+// run. It builds on the logger controller-runtime puts in the context of every reconcile, which
+// names the object being reconciled, and falls back to the package logger outside of one.
+func withOperation(ctx context.Context, operation string, keysAndValues ...any) context.Context {
+	logger, err := logr.FromContext(ctx)
+	if err != nil {
+		logger = log
+	} else {
+		logger = logger.WithName("provider").WithName("onepasswordsdk")
+	}
+	logger = logger.WithValues(append([]any{"operation", operation}, keysAndValues...)...)
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// loggerFrom returns the logger set by withOperation, or the package logger.
+func loggerFrom(ctx context.Context) logr.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(logr.Logger); ok {
+		return logger
+	}
+	return log
+}
+
+// redactReference keeps the vault and item of a secret reference, which is enough to tell
+// which item a log line is about, and masks the section, field and attribute after them.
+// References in the default vault start with the item.
+func redactReference(ref string) string {
+	scheme, n := "", 2
+	if strings.HasPrefix(ref, opReferencePrefix) {
+		scheme, n = opReferencePrefix, 3
+		ref = strings.TrimPrefix(ref, opReferencePrefix)
+	}
+	parts := strings.SplitN(ref, opReferenceSep, n)
+	if len(parts) == n {
+		parts[n-1] = redacted
+	}
+	return scheme + strings.Join(parts, opReferenceSep)
+}
+
+// redactor redacts the names of vaults, items, sections and fields out of errors and logs when
+// spec.provider.onepasswordsdk.redactReferences is set, and leaves them as they are otherwise.
+type redactor bool
+
+// name returns the hash of name.
+func (r redactor) name(name string) string {
+	if !r {
+		return name
+	}
+	sum := sha256.Sum256([]byte(name))
+	return redactedPrefix + hex.EncodeToString(sum[:])[:redactedHashLen]
+}
+
+// names returns the hash of every name.
+func (r redactor) names(names []string) []string {
+	if !r {
+		return names
+	}
+	hashed := make([]string, len(names))
+	for i, name := range names {
+		hashed[i] = r.name(name)
+	}
+	return hashed
+}
+
+// reference returns the secret reference as redactReference does, or with every segment
+// hashed, keeping the shape of the reference.
+func (r redactor) reference(ref string) string {
+	if !r {
+		return redactReference(ref)
+	}
+	scheme := ""
+	if strings.HasPrefix(ref, opReferencePrefix) {
+		scheme = opReferencePrefix
+		ref = strings.TrimPrefix(ref, opReferencePrefix)
+	}
+	return scheme + strings.Join(r.names(strings.Split(ref, opReferenceSep)), opReferenceSep)
+}
+
+// message hashes every quoted string of an error message, and the names in the lists it suggests.
+func (r redactor) message(msg string) string {
+	if !r {
+		return msg
+	}
+	msg = quotedPattern.ReplaceAllStringFunc(msg, func(quoted string) string {
+		s, err := strconv.Unquote(quoted)
+		if err != nil {
+			return quoted
+		}
+		return strconv.Quote(r.name(s))
+	})
+	return namesListPattern.ReplaceAllStringFunc(msg, func(list string) string {
+		match := namesListPattern.FindStringSubmatch(list)
+		return match[1] + ": " + strings.Join(r.names(strings.Split(match[2], namesListSep)), namesListSep)
+	})
+}
+
+// err returns err with its message redacted, still matching the errors it wraps.
+func (r redactor) err(err error) error {
+	if !r || err == nil {
+		return err
+	}
+	return &redactedError{err: err, msg: r.message(err.Error())}
+}
+
+type redactedError struct {
+	err error
+	msg string
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
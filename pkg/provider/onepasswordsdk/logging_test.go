@@ -0,0 +1,142 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/1password/onepassword-sdk-go"
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	"github.com/external-secrets/external-secrets/pkg/provider/onepasswordsdk/fake"
+	testingfake "github.com/external-secrets/external-secrets/pkg/provider/testing/fake"
+)
+
+func TestRedactReference(t *testing.T) {
+	tests := map[string]string{
+		"op://vault/item":                      "op://vault/item",
+		"op://vault/item/field":                "op://vault/item/***",
+		"op://vault/item/section/field":        "op://vault/item/***",
+		"op://vault/item/field?attribute=totp": "op://vault/item/***",
+		"item":                                 "item",
+		"item/section/field":                   "item/***",
+	}
+	for ref, want := range tests {
+		assert.Equal(t, want, redactReference(ref), ref)
+	}
+}
+
+func TestLogging(t *testing.T) {
+	var lines []string
+	logger := funcr.New(func(prefix, args string) {
+		lines = append(lines, prefix+" "+args)
+	}, funcr.Options{Verbosity: 1})
+	ctx := logr.NewContext(context.Background(), logger)
+
+	client := newFakeClient()
+	provider := &ProviderOnePasswordSdk{client: instrumentClient(client.SDKClient(), false)}
+	_, err := provider.GetSecret(ctx, esv1beta1.ExternalSecretDataRemoteRef{Key: "op://my-vault/my-item/key1"})
+	assert.NoError(t, err)
+	client.WithError(fake.ItemsGet, errors.New("forbidden"))
+	_, err = provider.GetSecretMap(ctx, esv1beta1.ExternalSecretDataRemoteRef{Key: "op://my-vault/my-item"})
+	assert.Error(t, err)
+
+	logs := strings.Join(lines, "\n")
+	assert.Contains(t, logs, `provider/onepasswordsdk "level"=1 "msg"="1Password API call" "operation"="GetSecret" "reference"="op://my-vault/my-item/***" "call"="SecretsResolve"`)
+	assert.Contains(t, logs, `"secretReference"="op://my-vault/my-item/***"`)
+	assert.Contains(t, logs, `"msg"="1Password API call failed" "operation"="GetSecretMap" "reference"="op://my-vault/my-item" "call"="ItemsGet"`)
+	assert.Contains(t, logs, `"error"="forbidden"`)
+	assert.NotContains(t, logs, value1)
+	assert.NotContains(t, logs, "key1")
+}
+
+func TestRedactor(t *testing.T) {
+	var off, on redactor = false, true
+	assert.Equal(t, "Payments", off.name("Payments"))
+	assert.Equal(t, "op://Payments/Stripe/***", off.reference("op://Payments/Stripe/api key"))
+	assert.Equal(t, `Item "Stripe"`, off.message(`Item "Stripe"`))
+
+	hashed := on.name("Payments")
+	assert.Regexp(t, `^sha256:[0-9a-f]{8}$`, hashed)
+	assert.Equal(t, hashed, on.name("Payments"))
+	assert.NotEqual(t, hashed, on.name("payments"))
+	assert.Equal(t, "op://"+hashed+"/"+on.name("Stripe"), on.reference("op://Payments/Stripe"))
+	assert.Equal(t, `1Password Item `+strconv.Quote(on.name("Stripe"))+` not found in Vault `+strconv.Quote(hashed),
+		on.message(`1Password Item "Stripe" not found in Vault "Payments"`))
+
+	assert.Equal(t, "available fields: "+on.name("api key")+", "+on.name("secret")+"; no field has that ID either",
+		on.message("available fields: api key, secret; no field has that ID either"))
+
+	msg := on.message(fmt.Sprintf(errFieldNotFound, "token", "Stripe", "api key, secret", "api key, h4r7nx2kq3"))
+	for _, name := range []string{"token", "Stripe", "api key", "secret", "h4r7nx2kq3"} {
+		assert.NotContains(t, msg, name)
+	}
+	assert.Contains(t, msg, "available IDs: "+on.name("api key")+", "+on.name("h4r7nx2kq3"))
+
+	err := on.err(newTypedError(ErrSecretNotFound, errors.New(`1Password Item "Stripe" not found`)))
+	assert.ErrorIs(t, err, ErrSecretNotFound)
+	assert.ErrorIs(t, err, esv1beta1.NoSecretErr)
+	assert.NotContains(t, err.Error(), "Stripe")
+	assert.Nil(t, on.err(nil))
+}
+
+func TestRedactReferences(t *testing.T) {
+	var lines []string
+	logger := funcr.New(func(prefix, args string) {
+		lines = append(lines, prefix+" "+args)
+	}, funcr.Options{Verbosity: 1})
+	ctx := logr.NewContext(context.Background(), logger)
+
+	client := fake.NewClient().
+		AddVault("v1", "Payments").
+		AddItem(onepassword.Item{
+			ID:      "i1",
+			Title:   "Stripe",
+			VaultID: "v1",
+			Tags:    []string{"billing"},
+			Fields:  []onepassword.ItemField{{ID: "f1", Title: "api key", Value: value1}},
+		})
+	provider := &ProviderOnePasswordSdk{client: instrumentClient(client.SDKClient(), true), redact: true, dryRun: true}
+	names := []string{"Payments", "Stripe", "api key", "webhook secret", "billing"}
+
+	_, notFound := provider.GetSecret(ctx, esv1beta1.ExternalSecretDataRemoteRef{Key: "op://Payments/Stripe/webhook secret"})
+	assert.ErrorIs(t, notFound, ErrSecretNotFound)
+	client.WithError(fake.ItemsGet, errors.New(`item "Stripe" is forbidden`))
+	_, denied := provider.GetSecretMap(ctx, esv1beta1.ExternalSecretDataRemoteRef{Key: "op://Payments/Stripe"})
+	assert.ErrorIs(t, denied, ErrPermissionDenied)
+	client.WithError(fake.ItemsGet, nil)
+	secret := &corev1.Secret{Data: map[string][]byte{"webhook secret": []byte(value2)}}
+	assert.NoError(t, provider.PushSecret(ctx, secret, testingfake.PushSecretData{SecretKey: "webhook secret", RemoteKey: "op://Payments/Stripe"}))
+	deleted, err := provider.DeleteSecretsByTag(ctx, "Payments", "billing")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+
+	logs := strings.Join(lines, "\n")
+	assert.Contains(t, logs, `"reference"="op://`+provider.redact.name("Payments")+"/"+provider.redact.name("Stripe")+`"`)
+	for _, name := range names {
+		assert.NotContains(t, notFound.Error(), name)
+		assert.NotContains(t, denied.Error(), name)
+		assert.NotContains(t, logs, name)
+	}
+}
@@ -0,0 +1,169 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"context"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/1password/onepassword-sdk-go"
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/external-secrets/external-secrets/pkg/constants"
+	"github.com/external-secrets/external-secrets/pkg/metrics"
+)
+
+const (
+	sdkModulePath     = "github.com/1password/onepassword-sdk-go"
+	unknownSDKVersion = "unknown"
+)
+
+var (
+	// sdkInfo is always 1, labeled with the version of the SDK the provider is built with, so that
+	// changes of behavior can be told apart from SDK upgrades.
+	sdkInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: metrics.ExternalSecretSubsystem,
+		Name:      "onepasswordsdk_sdk_info",
+		Help:      "Version of the 1Password SDK the onepasswordsdk provider is built with",
+	}, []string{"version"})
+	// circuitBreakerState is the state of the circuit breaker of each store: 0 when closed, 1 when
+	// open and 2 when half-open, letting a call through to check whether 1Password recovered.
+	circuitBreakerState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Subsystem: metrics.ExternalSecretSubsystem,
+		Name:      "onepasswordsdk_circuit_breaker_state",
+		Help:      "State of the circuit breaker of a onepasswordsdk store: 0 closed, 1 open, 2 half-open",
+	}, []string{"kind", "name", "namespace"})
+	logSDKVersion sync.Once
+)
+
+// sdkVersion returns the version of the SDK module compiled in, as recorded in the build info.
+func sdkVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return unknownSDKVersion
+	}
+	for _, dep := range info.Deps {
+		if dep.Path != sdkModulePath {
+			continue
+		}
+		if dep.Replace != nil {
+			return dep.Replace.Version
+		}
+		return dep.Version
+	}
+	return unknownSDKVersion
+}
+
+// logSDKVersionOnce logs the version of the SDK with the first client created. Logging it when
+// the package is initialized would be too early, before the logger of the controller is set.
+func logSDKVersionOnce() {
+	logSDKVersion.Do(func() {
+		log.Info("using the 1Password SDK", "version", sdkVersion())
+	})
+}
+
+// instrumentClient wraps every API of the SDK client so that each call to 1Password is
+// counted, timed and logged at debug level. Only identifiers are logged, never field values,
+// and names are redacted by redact.
+func instrumentClient(client onepassword.Client, redact redactor) onepassword.Client {
+	return onepassword.Client{
+		Secrets: &instrumentedSecrets{client.Secrets, redact},
+		Items:   &instrumentedItems{client.Items, redact},
+		Vaults:  &instrumentedVaults{client.Vaults, redact},
+	}
+}
+
+func observe(ctx context.Context, redact redactor, call string, start time.Time, err error, keysAndValues ...any) {
+	duration := time.Since(start)
+	metrics.ObserveAPICallDuration(constants.ProviderOnePasswordSDK, call, err, duration)
+	logger := loggerFrom(ctx).V(1).WithValues(append([]any{"call", call, "duration", duration}, keysAndValues...)...)
+	if err != nil {
+		logger.Info("1Password API call failed", "error", redact.message(err.Error()))
+		return
+	}
+	logger.Info("1Password API call")
+}
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(sdkInfo, circuitBreakerState)
+	sdkInfo.WithLabelValues(sdkVersion()).Set(1)
+}
+
+type instrumentedSecrets struct {
+	onepassword.SecretsAPI
+	redact redactor
+}
+
+func (s *instrumentedSecrets) Resolve(ctx context.Context, secretReference string) (string, error) {
+	start := time.Now()
+	result, err := s.SecretsAPI.Resolve(ctx, secretReference)
+	observe(ctx, s.redact, constants.CallOnePasswordSDKSecretsResolve, start, err, "secretReference", s.redact.reference(secretReference))
+	return result, err
+}
+
+type instrumentedItems struct {
+	onepassword.ItemsAPI
+	redact redactor
+}
+
+func (i *instrumentedItems) Create(ctx context.Context, params onepassword.ItemCreateParams) (onepassword.Item, error) {
+	start := time.Now()
+	result, err := i.ItemsAPI.Create(ctx, params)
+	observe(ctx, i.redact, constants.CallOnePasswordSDKItemsCreate, start, err, "vaultID", params.VaultID, "item", i.redact.name(params.Title))
+	return result, err
+}
+
+func (i *instrumentedItems) Get(ctx context.Context, vaultID, itemID string) (onepassword.Item, error) {
+	start := time.Now()
+	result, err := i.ItemsAPI.Get(ctx, vaultID, itemID)
+	observe(ctx, i.redact, constants.CallOnePasswordSDKItemsGet, start, err, "vaultID", vaultID, "itemID", itemID)
+	return result, err
+}
+
+func (i *instrumentedItems) Put(ctx context.Context, item onepassword.Item) (onepassword.Item, error) {
+	start := time.Now()
+	result, err := i.ItemsAPI.Put(ctx, item)
+	observe(ctx, i.redact, constants.CallOnePasswordSDKItemsPut, start, err, "vaultID", item.VaultID, "itemID", item.ID)
+	return result, err
+}
+
+func (i *instrumentedItems) Delete(ctx context.Context, vaultID, itemID string) error {
+	start := time.Now()
+	err := i.ItemsAPI.Delete(ctx, vaultID, itemID)
+	observe(ctx, i.redact, constants.CallOnePasswordSDKItemsDelete, start, err, "vaultID", vaultID, "itemID", itemID)
+	return err
+}
+
+func (i *instrumentedItems) ListAll(ctx context.Context, vaultID string) (*onepassword.Iterator[onepassword.ItemOverview], error) {
+	start := time.Now()
+	result, err := i.ItemsAPI.ListAll(ctx, vaultID)
+	observe(ctx, i.redact, constants.CallOnePasswordSDKItemsListAll, start, err, "vaultID", vaultID)
+	return result, err
+}
+
+type instrumentedVaults struct {
+	onepassword.VaultsAPI
+	redact redactor
+}
+
+func (v *instrumentedVaults) ListAll(ctx context.Context) (*onepassword.Iterator[onepassword.VaultOverview], error) {
+	start := time.Now()
+	result, err := v.VaultsAPI.ListAll(ctx)
+	observe(ctx, v.redact, constants.CallOnePasswordSDKVaultsListAll, start, err)
+	return result, err
+}
@@ -0,0 +1,104 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	"github.com/external-secrets/external-secrets/pkg/constants"
+	"github.com/external-secrets/external-secrets/pkg/provider/onepasswordsdk/fake"
+)
+
+// apiCalls returns the number of calls counted and timed for call with the given status.
+func apiCalls(t *testing.T, call, status string) (counted, timed uint64) {
+	t.Helper()
+	families, err := metrics.Registry.Gather()
+	assert.NoError(t, err)
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["provider"] != constants.ProviderOnePasswordSDK || labels["call"] != call || labels["status"] != status {
+				continue
+			}
+			switch family.GetName() {
+			case "externalsecret_provider_api_calls_count":
+				counted = uint64(metric.GetCounter().GetValue())
+			case "externalsecret_provider_api_call_duration_seconds":
+				timed = metric.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	return counted, timed
+}
+
+func TestInstrumentClient(t *testing.T) {
+	client := newFakeClient()
+	provider := &ProviderOnePasswordSdk{client: instrumentClient(client.SDKClient(), false)}
+	ref := esv1beta1.ExternalSecretDataRemoteRef{Key: "op://my-vault/my-item/key1"}
+
+	counted, timed := apiCalls(t, constants.CallOnePasswordSDKSecretsResolve, constants.StatusSuccess)
+	got, err := provider.GetSecret(context.Background(), ref)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(value1), got)
+	afterCounted, afterTimed := apiCalls(t, constants.CallOnePasswordSDKSecretsResolve, constants.StatusSuccess)
+	assert.Equal(t, counted+1, afterCounted)
+	assert.Equal(t, timed+1, afterTimed)
+
+	client.WithError(fake.SecretsResolve, errors.New("forbidden"))
+	counted, timed = apiCalls(t, constants.CallOnePasswordSDKSecretsResolve, constants.StatusError)
+	_, err = provider.GetSecret(context.Background(), ref)
+	assert.Error(t, err)
+	afterCounted, afterTimed = apiCalls(t, constants.CallOnePasswordSDKSecretsResolve, constants.StatusError)
+	assert.Equal(t, counted+1, afterCounted)
+	assert.Equal(t, timed+1, afterTimed)
+
+	// GetSecretMap resolves the notes of the item too
+	client.WithError(fake.SecretsResolve, nil)
+	counted, _ = apiCalls(t, constants.CallOnePasswordSDKItemsGet, constants.StatusSuccess)
+	_, err = provider.GetSecretMap(context.Background(), esv1beta1.ExternalSecretDataRemoteRef{Key: "op://my-vault/my-item"})
+	assert.NoError(t, err)
+	afterCounted, _ = apiCalls(t, constants.CallOnePasswordSDKItemsGet, constants.StatusSuccess)
+	assert.Equal(t, counted+1, afterCounted)
+}
+
+func TestSDKInfo(t *testing.T) {
+	version := sdkVersion()
+	assert.Regexp(t, `^v\d+\.\d+\.\d+`, version)
+
+	families, err := metrics.Registry.Gather()
+	assert.NoError(t, err)
+	var found bool
+	for _, family := range families {
+		if family.GetName() != "externalsecret_onepasswordsdk_sdk_info" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			assert.Equal(t, "version", metric.GetLabel()[0].GetName())
+			assert.Equal(t, version, metric.GetLabel()[0].GetValue())
+			assert.Equal(t, 1.0, metric.GetGauge().GetValue())
+			found = true
+		}
+	}
+	assert.True(t, found)
+}
@@ -1,17 +1,42 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
 package onepasswordsdk
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"maps"
+	"path"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/1password/onepassword-sdk-go"
-	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
-	"github.com/external-secrets/external-secrets/pkg/utils"
-	"github.com/external-secrets/external-secrets/pkg/utils/resolvers"
-	v1 "k8s.io/api/core/v1"
+	"golang.org/x/sync/singleflight"
+	"golang.org/x/time/rate"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	"github.com/external-secrets/external-secrets/pkg/utils"
 )
 
 const (
@@ -19,54 +44,329 @@ const (
 	errOnePasswordSdkStoreNilSpec                       = "nil spec"
 	errOnePasswordSdkStoreNilSpecProvider               = "nil spec.provider"
 	errOnePasswordSdkStoreNilSpecProviderOnePasswordSdk = "nil spec.provider.onepasswordsdk"
+	errOnePasswordSdkStoreAuth                          = "exactly one of spec.provider.onepasswordsdk.auth.serviceAccountSecretRef and serviceAccountTokenFile must be set"
+	errOnePasswordSdkStoreConnect                       = "spec.provider.onepasswordsdk.connectHost and auth.connectTokenSecretRef must be set together"
+	errOnePasswordSdkStoreConnectAccount                = "spec.provider.onepasswordsdk.account only applies to service account tokens, not to connectHost"
+	errOnePasswordSdkStoreInvalidAccount                = "spec.provider.onepasswordsdk.account %q is not a sign-in address such as my-team.1password.com"
+	errOnePasswordSdkStoreConnectAuth                   = "spec.provider.onepasswordsdk.auth.connectTokenSecretRef rules out service account tokens in spec.provider.onepasswordsdk.auth"
+	errOnePasswordSdkStoreMissingConnectRefName         = "missing: spec.provider.onepasswordsdk.auth.connectTokenSecretRef.name"
+	errOnePasswordSdkStoreMissingConnectRefKey          = "missing: spec.provider.onepasswordsdk.auth.connectTokenSecretRef.key"
+	errOnePasswordSdkStoreMissingConnectRefNamespace    = "missing: spec.provider.onepasswordsdk.auth.connectTokenSecretRef.namespace, required in a ClusterSecretStore"
 	errOnePasswordSdkStoreMissingRefName                = "missing: spec.provider.onepasswordsdk.auth.secretRef.serviceAccountTokenSecretRef.name"
 	errOnePasswordSdkStoreMissingRefKey                 = "missing: spec.provider.onepasswordsdk.auth.secretRef.serviceAccountTokenSecretRef.key"
+	errOnePasswordSdkStoreMissingRefNamespace           = "missing: spec.provider.onepasswordsdk.auth.serviceAccountSecretRef.namespace, required in a ClusterSecretStore"
+	errOnePasswordSdkStoreMissingFallbackRefName        = "missing: spec.provider.onepasswordsdk.auth.fallbackServiceAccountSecretRefs[].name"
+	errOnePasswordSdkStoreMissingFallbackRefKey         = "missing: spec.provider.onepasswordsdk.auth.fallbackServiceAccountSecretRefs[].key"
+	errOnePasswordSdkStoreMissingFallbackRefNamespace   = "missing: spec.provider.onepasswordsdk.auth.fallbackServiceAccountSecretRefs[].namespace, required in a ClusterSecretStore"
+	errOnePasswordSdkStoreEmptyVault                    = "empty vault in spec.provider.onepasswordsdk.vaults"
+	errOnePasswordSdkStoreDefaultVaultNotAllowed        = "spec.provider.onepasswordsdk.defaultVault is not in spec.provider.onepasswordsdk.vaults"
+	errOnePasswordSdkStoreNegativeTimeout               = "negative spec.provider.onepasswordsdk.requestTimeout"
+	errOnePasswordSdkStoreNegativeCacheTTL              = "negative spec.provider.onepasswordsdk.cacheTTL"
+	errOnePasswordSdkStoreNegativeVaultCacheTTL         = "negative spec.provider.onepasswordsdk.vaultCacheTTL"
+	errOnePasswordSdkStoreNegativeRuleTTL               = "negative ttl in spec.provider.onepasswordsdk.cacheTTLRules[%d]"
+	errOnePasswordSdkStoreRulePattern                   = "invalid pattern %q in spec.provider.onepasswordsdk.cacheTTLRules[%d]: %w"
+	errOnePasswordSdkStoreNegativeMaxItems              = "negative spec.provider.onepasswordsdk.maxItems"
+	errOnePasswordSdkStoreNegativeConcurrency           = "negative spec.provider.onepasswordsdk.getAllSecretsConcurrency"
+	errOnePasswordSdkStoreFailureThreshold              = "spec.provider.onepasswordsdk.circuitBreaker.failureThreshold must be at least 1"
+	errOnePasswordSdkStoreNegativeCoolDown              = "negative spec.provider.onepasswordsdk.circuitBreaker.coolDown"
+	errOnePasswordSdkStoreNegativeRequestsPerSecond     = "negative spec.provider.onepasswordsdk.requestsPerSecond"
+	errOnePasswordSdkStoreWriteOnlyDryRun               = "spec.provider.onepasswordsdk.writeOnly and dryRun together make a store that neither reads nor writes secrets"
+	errOnePasswordSdkStoreWriteOnlyReadOption           = "spec.provider.onepasswordsdk.%s only applies to reading secrets, which spec.provider.onepasswordsdk.writeOnly rules out"
+	errOnePasswordSdkStoreEmptyDecodingField            = "empty field label in spec.provider.onepasswordsdk.fieldDecodingStrategies"
+	errOnePasswordSdkStoreEmptyFieldMap                 = "empty key or field ID in spec.provider.onepasswordsdk.fieldMap"
+	errOnePasswordSdkStoreEmptyIncludeField             = "empty key in spec.provider.onepasswordsdk.includeFields"
+	errOnePasswordSdkStoreEmptyExcludeField             = "empty key in spec.provider.onepasswordsdk.excludeFields"
+	errOnePasswordSdkStoreEmptyManagedMarker            = "spec.provider.onepasswordsdk.managedMarker sets neither tag nor sourceField"
+	errOnePasswordSdkStoreDecodingStrategy              = "unsupported decoding strategy %q of field %q in spec.provider.onepasswordsdk.fieldDecodingStrategies"
+	errOnePasswordSdkStoreConversionStrategy            = "unsupported spec.provider.onepasswordsdk.defaultConversionStrategy %q"
+	errOnePasswordSdkStoreDefaultDecodingStrategy       = "unsupported spec.provider.onepasswordsdk.defaultDecodingStrategy %q"
+
+	errListVaults         = "error listing 1Password Vaults: %w"
+	errListItems          = "error listing 1Password Items: %w"
+	errGetItem            = "error getting 1Password Item: %w"
+	errNoVaults           = "the service account cannot access any 1Password Vault"
+	errVaultNotFound      = "1Password Vault %q not found or not accessible to the service account"
+	errVaultNotAllowed    = "1Password Vault %q is not allowed by spec.provider.onepasswordsdk.vaults"
+	errItemNotFound       = "1Password Item %q not found in Vault %q"
+	errItemIDNotFound     = "1Password Item with ID %q not found in any accessible Vault"
+	errExpectedOneItem    = "expected one 1Password Item matching %q in Vault %q, got %d"
+	errAmbiguousVaultName = "more than one 1Password Vault matches %q ignoring case: %s, use the exact title or ID, or set spec.provider.onepasswordsdk.strictNameMatching"
+	errAmbiguousItemName  = "more than one 1Password Item matches %q in Vault %q ignoring case: %s, use the exact title or ID"
+	errExpectedOneField   = "expected one 1Password ItemField labeled %q in Item %q"
+	errFieldMapKey        = "key %q of spec.provider.onepasswordsdk.fieldMap collides with another 1Password ItemField of Item %q"
+	errAmbiguousField     = "1Password ItemField %q is in more than one section of Item %q, qualify it as op://<vault>/<item>/<section>/<field> with one of: %s"
+	errSectionNotFound    = "1Password Section %q not found in Item %q"
+	errFieldNotFound      = "1Password ItemField %q not found in Item %q, available fields: %s; no field has that ID either, available IDs: %s"
+	errFieldsNotFound     = "1Password ItemFields %s not found in Item %q"
+	errEmptyPropertyList  = "empty field label in remoteRef.property %q, expected a comma separated list of field labels"
+	errVersionNotFound    = "version %q of 1Password Item %q not found, available versions: %d"
+	errRelativeVersion    = "remoteRef.version %q is not supported by the 1Password SDK: it only reads the current version of an item, not its history"
+	errMetadataPrefix     = "1Password ItemField %q of Item %q starts with %s, which is reserved for the metadata spec.provider.onepasswordsdk.includeMetadata adds"
+	errDocumentItem       = "1Password Item %q is a Document, reading its file is not supported by the 1Password SDK"
+	errNoWebsite          = "1Password Login Item %q has no website"
+	errLoginKey           = "1Password ItemField labeled %q in Item %q collides with the %s of the Login item, returned under that key"
+	errFieldMetadata      = "remoteRef.metadataPolicy Fetch is not supported for 1Password ItemField references: the 1Password SDK exposes no metadata of a field, such as the strength of a password"
+	errNotTOTPField       = "1Password ItemField %q of Item %q is not a one-time password"
+	errWriteOnlyStore     = "the 1Password SDK SecretStore is write-only, spec.provider.onepasswordsdk.writeOnly is set"
+	errUnavailable        = "1Password is unavailable: %w"
+	errTokenRejected      = "1Password rejected the service account token: %w"
+	errTOTPCode           = "could not compute the one-time password of 1Password ItemField %q: %s"
+	errDecodeField        = "error decoding 1Password ItemField %q of Item %q as %s: %w"
+
+	otpauthScheme = "otpauth://"
+	// propertyListSep separates the fields of a remoteRef.property read together as a JSON object.
+	propertyListSep = ","
+	// sdkAmbiguousField is the message of the SDK when a field label matches more than one field.
+	sdkAmbiguousField = "more than one field matched"
+
+	// sshKeyPrivateKey, sshKeyPublicKey and sshKeyFingerprint are the IDs of the fields of SSH key
+	// items, and the keys GetSecretMap returns them under.
+	sshKeyPrivateKey  = "private_key"
+	sshKeyPublicKey   = "public_key"
+	sshKeyFingerprint = "fingerprint"
 
-	errVersionNotImplemented = "'remoteRef.version' is not implemented in the 1Password SDK provider"
-	errDeleteNotImplemented  = "DeleteSecret is not supported"
+	// notesPlain is the ID of the notes of an item, and the key GetSecretMap returns them under.
+	// notesProperty is the shorter property reading them too.
+	notesPlain    = "notesPlain"
+	notesProperty = "notes"
+	// urlProperty reads the website of a Login item, which the SDK models as its first URL field.
+	urlProperty = "url"
+	// loginUsername and loginPassword are the IDs of the built-in fields of a Login item, which
+	// GetSecretMap returns under their ID whatever their label, localized by the 1Password apps.
+	loginUsername = "username"
+	loginPassword = "password"
 
-	errNotImplemented = "not implemented"
+	metadataID            = "id"
+	metadataItemID        = "item_id"
+	metadataTitle         = "title"
+	metadataCategory      = "category"
+	metadataVault         = "vault"
+	metadataTags          = "tags"
+	metadataVersion       = "version"
+	metadataURL           = "url"
+	metadataTagsSeparator = ","
+	// metadataPrefix is the reserved prefix of the metadata keys includeMetadata adds to the fields.
+	metadataPrefix = "_metadata_"
+
+	warnFieldIncludedAndExcluded = "spec.provider.onepasswordsdk.includeFields and excludeFields both list %q, which is left out"
+
+	defaultIntegrationName = "external-secrets"
+	develBuildVersion      = "(devel)"
+
+	defaultVaultCacheTTL = 10 * time.Second
+	// previousVersion and previousVersionOffset are the values of remoteRef.version selecting the
+	// version of an item before its current one, which the SDK cannot read.
+	previousVersion       = "previous"
+	previousVersionOffset = "-1"
+	// vaultListKey keys the only entry of the vault list cache.
+	vaultListKey = ""
 )
 
+var log = ctrl.Log.WithName("provider").WithName("onepasswordsdk")
+
 type ProviderOnePasswordSdk struct {
-	client onepassword.Client
+	// sdkClient is only held on to so that the SDK does not release it while in use: the
+	// client is released in the WASM core once garbage collected.
+	sdkClient      *onepassword.Client
+	client         onepassword.Client
+	connect        connectFunc
+	closed         bool
+	vaults         []string
+	defaultVault   string
+	strictNames    bool
+	redact         redactor
+	requestTimeout time.Duration
+	limiter        *rate.Limiter
+	breaker        *circuitBreaker
+	resolves       *singleflight.Group
+	cache          *secretCache
+	itemIDs        *ttlCache[string]
+	vaultList      *ttlCache[[]onepassword.VaultOverview]
+	// revalidation refreshes the expired vault list in the background when revalidateVaults is set
+	revalidateVaults bool
+	revalidation     revalidation
+	retrier          *retrier
+
+	continueOnError    bool
+	maxItems           int
+	concurrency        int
+	ignoreMissing      bool
+	allowNoVaults      bool
+	writeOnly          bool
+	dryRun             bool
+	marker             *esv1beta1.OnePasswordSdkManagedMarker
+	protectedTag       string
+	includeMetadata    bool
+	fieldDecoding      map[string]esv1beta1.ExternalSecretDecodingStrategy
+	defaultConversion  esv1beta1.ExternalSecretConversionStrategy
+	defaultDecoding    esv1beta1.ExternalSecretDecodingStrategy
+	fieldMap           map[string]string
+	includeFields      []string
+	excludeFields      []string
+	omitEmptyFields    bool
+	validationStrategy esv1beta1.OnePasswordSdkValidationStrategy
 }
 
-// Capabilities implements v1beta1.Provider.
+// Capabilities implements v1beta1.Provider. A store in dry run is still read-write, so that
+// PushSecrets go through the whole write path up to the point of writing.
 func (provider *ProviderOnePasswordSdk) Capabilities() esv1beta1.SecretStoreCapabilities {
-	return esv1beta1.SecretStoreReadOnly
+	if provider.writeOnly {
+		return esv1beta1.SecretStoreWriteOnly
+	}
+	return esv1beta1.SecretStoreReadWrite
+}
+
+// checkReadable fails the methods reading secrets out of a write-only store.
+func (provider *ProviderOnePasswordSdk) checkReadable() error {
+	if provider.Capabilities() == esv1beta1.SecretStoreWriteOnly {
+		return errors.New(errWriteOnlyStore)
+	}
+	return nil
 }
 
 // NewClient implements v1beta1.Provider.
 func (provider *ProviderOnePasswordSdk) NewClient(ctx context.Context, store esv1beta1.GenericStore, kube client.Client, namespace string) (esv1beta1.SecretsClient, error) {
+	logSDKVersionOnce()
 	config := store.GetSpec().Provider.OnePasswordSdk
-	serviceAccountToken, err := resolvers.SecretKeyRef(
-		ctx,
-		kube,
-		store.GetKind(),
-		namespace,
-		&config.Auth.ServiceAccountSecretRef,
-	)
+	var connect connectFunc
+	if config.ConnectHost != "" {
+		connect = newConnectServerFunc(config, kube, store.GetKind(), namespace)
+	} else {
+		if err := checkSDKCore(ctx); err != nil {
+			return nil, err
+		}
+		connect = newServiceAccountFunc(config, kube, store.GetKind(), namespace)
+	}
+	sdkClient, err := connect(ctx)
 	if err != nil {
 		return nil, err
 	}
-	client, err := onepassword.NewClient(
-		ctx,
-		onepassword.WithServiceAccountToken(serviceAccountToken),
-		// TODO: Set the following to your own integration name and version.
-		onepassword.WithIntegrationInfo("My 1Password Integration", "v1.0.0"),
-	)
+
+	var requestTimeout time.Duration
+	if config.RequestTimeout != nil {
+		requestTimeout = config.RequestTimeout.Duration
+	}
+	vaultCacheTTL := defaultVaultCacheTTL
+	if config.VaultCacheTTL != nil {
+		vaultCacheTTL = config.VaultCacheTTL.Duration
+	}
+	var vaultList *ttlCache[[]onepassword.VaultOverview]
+	if vaultCacheTTL > 0 {
+		vaultList = newTTLCache[[]onepassword.VaultOverview](vaultCacheTTL)
+	}
+
+	retrier, err := newRetrier(store.GetSpec().RetrySettings)
 	if err != nil {
 		return nil, err
 	}
-	provider.client = *client
+	var limiter *rate.Limiter
+	if config.RequestsPerSecond > 0 {
+		limiter = storeLimiter(store, namespace, config.RequestsPerSecond)
+	}
+	var breaker *circuitBreaker
+	if config.CircuitBreaker != nil {
+		breaker = storeBreaker(store, namespace, config.CircuitBreaker)
+	}
+	var secretCache *secretCache
+	if cacheEnabled(config) {
+		ttls := cacheTTLs{
+			rules:        config.CacheTTLRules,
+			defaultVault: config.DefaultVault,
+			strictNames:  config.StrictNameMatching,
+		}
+		if config.CacheTTL != nil {
+			ttls.defaultTTL = config.CacheTTL.Duration
+		}
+		secretCache = storeSecretCache(store, namespace, ttls)
+	}
+
+	onePasswordSdk := &ProviderOnePasswordSdk{
+		connect:          connect,
+		vaults:           config.Vaults,
+		defaultVault:     config.DefaultVault,
+		strictNames:      config.StrictNameMatching,
+		redact:           redactor(config.RedactReferences),
+		requestTimeout:   requestTimeout,
+		limiter:          limiter,
+		breaker:          breaker,
+		resolves:         storeResolveGroup(store, namespace),
+		cache:            secretCache,
+		itemIDs:          newTTLCache[string](itemIDTTL),
+		vaultList:        vaultList,
+		revalidateVaults: config.VaultCacheStaleWhileRevalidate,
+		retrier:          retrier,
 
-	return provider, nil
+		continueOnError:    config.ContinueOnError,
+		maxItems:           config.MaxItems,
+		concurrency:        config.GetAllSecretsConcurrency,
+		ignoreMissing:      config.IgnoreMissing,
+		allowNoVaults:      config.RequireVaults != nil && !*config.RequireVaults,
+		writeOnly:          config.WriteOnly,
+		dryRun:             config.DryRun,
+		marker:             config.ManagedMarker,
+		protectedTag:       config.DeletionProtectionTag,
+		includeMetadata:    config.IncludeMetadata,
+		fieldDecoding:      config.FieldDecodingStrategies,
+		defaultConversion:  config.DefaultConversionStrategy,
+		defaultDecoding:    config.DefaultDecodingStrategy,
+		fieldMap:           config.FieldMap,
+		includeFields:      config.IncludeFields,
+		excludeFields:      config.ExcludeFields,
+		omitEmptyFields:    config.OmitEmptyFields,
+		validationStrategy: config.ValidationStrategy,
+	}
+	onePasswordSdk.useClient(sdkClient)
+	return onePasswordSdk, nil
+}
+
+// newServiceAccountFunc returns a connectFunc signing in to 1Password with the service account
+// tokens of the store.
+func newServiceAccountFunc(config *esv1beta1.OnePasswordSdkProvider, kube client.Client, storeKind, namespace string) connectFunc {
+	return newConnectFunc(config.Auth, config.Account, kube, storeKind, namespace, func(ctx context.Context, token string) (*onepassword.Client, error) {
+		// the SDK has no option for the server URL: it signs in to the address encoded in the
+		// service account token, which covers custom domains and the .ca and .eu regions, and its
+		// WASM core cannot reach any host outside of 1Password's own domains: a self-hosted
+		// Connect server is gone through with connectHost instead.
+		// Nor does it take an HTTP client: its requests go through http.DefaultClient, so a proxy
+		// is configured for the whole controller with HTTPS_PROXY and NO_PROXY, not per store.
+		return clientFactory(
+			ctx,
+			onepassword.WithServiceAccountToken(token),
+			onepassword.WithIntegrationInfo(integrationInfo(config)),
+		)
+	})
+}
+
+// useClient makes every following call go through sdkClient. The vaults listed by the previous
+// client are dropped, as another service account token may not see the same vaults.
+func (provider *ProviderOnePasswordSdk) useClient(sdkClient *onepassword.Client) {
+	provider.sdkClient = sdkClient
+	// resolves are coalesced first, so that the calls joining one in flight neither wait for the
+	// limiter nor count as API calls, and the breaker fails calls before they wait for the limiter
+	provider.client = coalesceClient(guardClient(limitClient(instrumentClient(*sdkClient, provider.redact), provider.limiter), provider.breaker), provider.resolves)
+	// vaults still being listed by the previous client would be cached for this one
+	provider.revalidation.stop()
+	provider.vaultList.delete(vaultListKey)
 }
 
-// ValidateStore checks if the provided store is valid.
+// ValidateStore checks if the provided store is valid. The admission webhook has neither the
+// service account token nor a client to read it with, so vaults can't be looked up here:
+// whether they exist is only checked once the controller validates the store with Validate.
+// A service account token pasted into the auth spec is rejected, and anything looking like part
+// of one is warned about. With SetValidationClient, so are the token Secrets that do not exist.
 func (provider *ProviderOnePasswordSdk) ValidateStore(store esv1beta1.GenericStore) (admission.Warnings, error) {
-	return nil, validateStore(store)
+	if err := validateStore(store); err != nil {
+		return nil, err
+	}
+	config := store.GetSpec().Provider.OnePasswordSdk
+	warnings, _ := checkInlineTokens(config.Auth)
+	warnings = append(warnings, checkFieldLists(config)...)
+	if validationClient != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), secretCheckTimeout)
+		defer cancel()
+		warnings = append(warnings, checkTokenSecrets(ctx, validationClient, store)...)
+	}
+	return warnings, nil
 }
 
 func validateStore(store esv1beta1.GenericStore) error {
@@ -82,15 +382,129 @@ func validateStore(store esv1beta1.GenericStore) error {
 	}
 
 	config := storeSpec.Provider.OnePasswordSdk
-	if config.Auth.ServiceAccountSecretRef.Name == "" {
-		return fmt.Errorf(errOnePasswordSdkStore, errors.New(errOnePasswordSdkStoreMissingRefName))
+	if config.ConnectHost != "" || (config.Auth != nil && config.Auth.ConnectTokenSecretRef != nil) {
+		if err := validateConnectAuth(store, config); err != nil {
+			return fmt.Errorf(errOnePasswordSdkStore, err)
+		}
+	} else if config.Auth == nil || (config.Auth.ServiceAccountSecretRef == nil) == (config.Auth.ServiceAccountTokenFile == "") {
+		return fmt.Errorf(errOnePasswordSdkStore, errors.New(errOnePasswordSdkStoreAuth))
 	}
-	if config.Auth.ServiceAccountSecretRef.Key == "" {
-		return fmt.Errorf(errOnePasswordSdkStore, errors.New(errOnePasswordSdkStoreMissingRefKey))
+	if _, err := checkInlineTokens(config.Auth); err != nil {
+		return fmt.Errorf(errOnePasswordSdkStore, err)
+	}
+	if config.Account != "" {
+		if config.ConnectHost != "" {
+			return fmt.Errorf(errOnePasswordSdkStore, errors.New(errOnePasswordSdkStoreConnectAccount))
+		}
+		if !validAccount(config.Account) {
+			return fmt.Errorf(errOnePasswordSdkStore, fmt.Errorf(errOnePasswordSdkStoreInvalidAccount, config.Account))
+		}
+	}
+	if ref := config.Auth.ServiceAccountSecretRef; ref != nil {
+		if ref.Name == "" {
+			return fmt.Errorf(errOnePasswordSdkStore, errors.New(errOnePasswordSdkStoreMissingRefName))
+		}
+		if ref.Key == "" {
+			return fmt.Errorf(errOnePasswordSdkStore, errors.New(errOnePasswordSdkStoreMissingRefKey))
+		}
+		if store.GetKind() == esv1beta1.ClusterSecretStoreKind && ref.Namespace == nil {
+			return fmt.Errorf(errOnePasswordSdkStore, errors.New(errOnePasswordSdkStoreMissingRefNamespace))
+		}
+
+		// check namespace compared to kind
+		if err := utils.ValidateSecretSelector(store, *ref); err != nil {
+			return fmt.Errorf(errOnePasswordSdkStore, err)
+		}
+	}
+	for _, ref := range config.Auth.FallbackServiceAccountSecretRefs {
+		if ref.Name == "" {
+			return fmt.Errorf(errOnePasswordSdkStore, errors.New(errOnePasswordSdkStoreMissingFallbackRefName))
+		}
+		if ref.Key == "" {
+			return fmt.Errorf(errOnePasswordSdkStore, errors.New(errOnePasswordSdkStoreMissingFallbackRefKey))
+		}
+		if store.GetKind() == esv1beta1.ClusterSecretStoreKind && ref.Namespace == nil {
+			return fmt.Errorf(errOnePasswordSdkStore, errors.New(errOnePasswordSdkStoreMissingFallbackRefNamespace))
+		}
+		if err := utils.ValidateSecretSelector(store, ref); err != nil {
+			return fmt.Errorf(errOnePasswordSdkStore, err)
+		}
 	}
 
-	// check namespace compared to kind
-	if err := utils.ValidateSecretSelector(store, config.Auth.ServiceAccountSecretRef); err != nil {
+	if slices.Contains(config.Vaults, "") {
+		return fmt.Errorf(errOnePasswordSdkStore, errors.New(errOnePasswordSdkStoreEmptyVault))
+	}
+	if config.DefaultVault != "" && len(config.Vaults) > 0 && !containsTitle(config.Vaults, config.DefaultVault, config.StrictNameMatching) {
+		return fmt.Errorf(errOnePasswordSdkStore, errors.New(errOnePasswordSdkStoreDefaultVaultNotAllowed))
+	}
+	if config.RequestTimeout != nil && config.RequestTimeout.Duration < 0 {
+		return fmt.Errorf(errOnePasswordSdkStore, errors.New(errOnePasswordSdkStoreNegativeTimeout))
+	}
+	if config.CacheTTL != nil && config.CacheTTL.Duration < 0 {
+		return fmt.Errorf(errOnePasswordSdkStore, errors.New(errOnePasswordSdkStoreNegativeCacheTTL))
+	}
+	if config.VaultCacheTTL != nil && config.VaultCacheTTL.Duration < 0 {
+		return fmt.Errorf(errOnePasswordSdkStore, errors.New(errOnePasswordSdkStoreNegativeVaultCacheTTL))
+	}
+	for i, rule := range config.CacheTTLRules {
+		if rule.TTL.Duration < 0 {
+			return fmt.Errorf(errOnePasswordSdkStore, fmt.Errorf(errOnePasswordSdkStoreNegativeRuleTTL, i))
+		}
+		for _, pattern := range []string{rule.Vault, rule.Item} {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf(errOnePasswordSdkStore, fmt.Errorf(errOnePasswordSdkStoreRulePattern, pattern, i, err))
+			}
+		}
+	}
+	if config.MaxItems < 0 {
+		return fmt.Errorf(errOnePasswordSdkStore, errors.New(errOnePasswordSdkStoreNegativeMaxItems))
+	}
+	if config.GetAllSecretsConcurrency < 0 {
+		return fmt.Errorf(errOnePasswordSdkStore, errors.New(errOnePasswordSdkStoreNegativeConcurrency))
+	}
+	if breaker := config.CircuitBreaker; breaker != nil {
+		if breaker.FailureThreshold < 1 {
+			return fmt.Errorf(errOnePasswordSdkStore, errors.New(errOnePasswordSdkStoreFailureThreshold))
+		}
+		if breaker.CoolDown != nil && breaker.CoolDown.Duration < 0 {
+			return fmt.Errorf(errOnePasswordSdkStore, errors.New(errOnePasswordSdkStoreNegativeCoolDown))
+		}
+	}
+	if config.RequestsPerSecond < 0 {
+		return fmt.Errorf(errOnePasswordSdkStore, errors.New(errOnePasswordSdkStoreNegativeRequestsPerSecond))
+	}
+	if err := checkWriteOnly(config); err != nil {
+		return fmt.Errorf(errOnePasswordSdkStore, err)
+	}
+	if marker := config.ManagedMarker; marker != nil && strings.TrimSpace(marker.Tag) == "" && strings.TrimSpace(marker.SourceField) == "" {
+		return fmt.Errorf(errOnePasswordSdkStore, errors.New(errOnePasswordSdkStoreEmptyManagedMarker))
+	}
+	for key, fieldID := range config.FieldMap {
+		if strings.TrimSpace(key) == "" || strings.TrimSpace(fieldID) == "" {
+			return fmt.Errorf(errOnePasswordSdkStore, errors.New(errOnePasswordSdkStoreEmptyFieldMap))
+		}
+	}
+	if slices.ContainsFunc(config.IncludeFields, func(key string) bool { return strings.TrimSpace(key) == "" }) {
+		return fmt.Errorf(errOnePasswordSdkStore, errors.New(errOnePasswordSdkStoreEmptyIncludeField))
+	}
+	if slices.ContainsFunc(config.ExcludeFields, func(key string) bool { return strings.TrimSpace(key) == "" }) {
+		return fmt.Errorf(errOnePasswordSdkStore, errors.New(errOnePasswordSdkStoreEmptyExcludeField))
+	}
+	if !validConversionStrategy(config.DefaultConversionStrategy) {
+		return fmt.Errorf(errOnePasswordSdkStore, fmt.Errorf(errOnePasswordSdkStoreConversionStrategy, config.DefaultConversionStrategy))
+	}
+	if _, err := utils.Decode(config.DefaultDecodingStrategy, nil); err != nil {
+		return fmt.Errorf(errOnePasswordSdkStore, fmt.Errorf(errOnePasswordSdkStoreDefaultDecodingStrategy, config.DefaultDecodingStrategy))
+	}
+	for field, strategy := range config.FieldDecodingStrategies {
+		if field == "" {
+			return fmt.Errorf(errOnePasswordSdkStore, errors.New(errOnePasswordSdkStoreEmptyDecodingField))
+		}
+		if _, err := utils.Decode(strategy, nil); err != nil || strategy == "" {
+			return fmt.Errorf(errOnePasswordSdkStore, fmt.Errorf(errOnePasswordSdkStoreDecodingStrategy, strategy, field))
+		}
+	}
+	if _, err := newRetrier(storeSpec.RetrySettings); err != nil {
 		return fmt.Errorf(errOnePasswordSdkStore, err)
 	}
 
@@ -98,66 +512,1035 @@ func validateStore(store esv1beta1.GenericStore) error {
 
 }
 
-// GetSecret returns a single secret from the provider.
+// checkFieldLists warns about the keys both includeFields and excludeFields list, which
+// excludeFields wins: they are never returned.
+func checkFieldLists(config *esv1beta1.OnePasswordSdkProvider) []string {
+	var warnings []string
+	for _, key := range config.IncludeFields {
+		if slices.Contains(config.ExcludeFields, key) {
+			warnings = append(warnings, fmt.Sprintf(warnFieldIncludedAndExcluded, key))
+		}
+	}
+	return warnings
+}
+
+// validateConnectAuth checks that a store going through a 1Password Connect server has both its
+// host and token, and no service account token besides.
+func validateConnectAuth(store esv1beta1.GenericStore, config *esv1beta1.OnePasswordSdkProvider) error {
+	if config.ConnectHost == "" || config.Auth == nil || config.Auth.ConnectTokenSecretRef == nil {
+		return errors.New(errOnePasswordSdkStoreConnect)
+	}
+	auth := config.Auth
+	if auth.ServiceAccountSecretRef != nil || auth.ServiceAccountTokenFile != "" || len(auth.FallbackServiceAccountSecretRefs) > 0 {
+		return errors.New(errOnePasswordSdkStoreConnectAuth)
+	}
+	ref := auth.ConnectTokenSecretRef
+	if ref.Name == "" {
+		return errors.New(errOnePasswordSdkStoreMissingConnectRefName)
+	}
+	if ref.Key == "" {
+		return errors.New(errOnePasswordSdkStoreMissingConnectRefKey)
+	}
+	if store.GetKind() == esv1beta1.ClusterSecretStoreKind && ref.Namespace == nil {
+		return errors.New(errOnePasswordSdkStoreMissingConnectRefNamespace)
+	}
+	return utils.ValidateSecretSelector(store, *ref)
+}
+
+// checkWriteOnly rejects the options that contradict writeOnly: those only reading secrets
+// uses, and dryRun, which leaves the store nothing to do at all.
+func checkWriteOnly(config *esv1beta1.OnePasswordSdkProvider) error {
+	if !config.WriteOnly {
+		return nil
+	}
+	if config.DryRun {
+		return errors.New(errOnePasswordSdkStoreWriteOnlyDryRun)
+	}
+	readOptions := []struct {
+		name string
+		set  bool
+	}{
+		{"ignoreMissing", config.IgnoreMissing},
+		{"continueOnError", config.ContinueOnError},
+		{"maxItems", config.MaxItems > 0},
+		{"getAllSecretsConcurrency", config.GetAllSecretsConcurrency > 0},
+		{"cacheTTL", config.CacheTTL != nil && config.CacheTTL.Duration > 0},
+		{"cacheTTLRules", len(config.CacheTTLRules) > 0},
+	}
+	for _, option := range readOptions {
+		if option.set {
+			return fmt.Errorf(errOnePasswordSdkStoreWriteOnlyReadOption, option.name)
+		}
+	}
+	return nil
+}
+
+// GetSecret returns the value of the field referenced as op://<vault>/<item>[/<section>]/<field>,
+// or as op://<vault>/<item> with remoteRef.property naming the field, as stored.
 func (provider *ProviderOnePasswordSdk) GetSecret(ctx context.Context, ref esv1beta1.ExternalSecretDataRemoteRef) ([]byte, error) {
-	if ref.Version != "" {
-		return nil, errors.New(errVersionNotImplemented)
+	if err := provider.checkReadable(); err != nil {
+		return nil, err
+	}
+	ctx = withOperation(ctx, "GetSecret", "reference", provider.redact.reference(ref.Key))
+	if value, ok := provider.cache.getSecret(ref); ok {
+		return value, nil
 	}
-	// TODO: maybe return an error if the ref.Key is not a valid op url ("op://vault/item/field")
-	secret, err := provider.client.Secrets.Resolve(ctx, ref.Key)
+	ctx, cancel := provider.withTimeout(ctx)
+	defer cancel()
+	value, err := reauth(ctx, provider, func() ([]byte, error) {
+		return retry(ctx, provider.retrier, func() ([]byte, error) {
+			return provider.getSecret(ctx, ref)
+		})
+	})
+	if err != nil {
+		return provider.missingSecret(ctx, mapError(err))
+	}
+	provider.cache.addSecret(ref, value)
+	return value, nil
+}
+
+// missingSecret returns an empty value in place of ErrSecretNotFound when missing secrets are
+// ignored, and err otherwise.
+func (provider *ProviderOnePasswordSdk) missingSecret(ctx context.Context, err error) ([]byte, error) {
+	if !provider.ignoreMissing || !errors.Is(err, ErrSecretNotFound) {
+		return nil, provider.redact.err(err)
+	}
+	loggerFrom(ctx).V(1).Info("ignoring missing 1Password secret")
+	return []byte{}, nil
+}
+
+func (provider *ProviderOnePasswordSdk) getSecret(ctx context.Context, ref esv1beta1.ExternalSecretDataRemoteRef) ([]byte, error) {
+	if ref.MetadataPolicy == esv1beta1.ExternalSecretMetadataPolicyFetch {
+		return nil, errors.New(errFieldMetadata)
+	}
+	if err := checkVersion(ref.Version); err != nil {
+		return nil, err
+	}
+	value, err := provider.readSecret(ctx, ref)
 	if err != nil {
 		return nil, err
 	}
+	return provider.decodeDefault(ref, value)
+}
+
+// readSecret is getSecret before decoding with the default decoding strategy of the store.
+func (provider *ProviderOnePasswordSdk) readSecret(ctx context.Context, ref esv1beta1.ExternalSecretDataRemoteRef) ([]byte, error) {
+	secretRef, err := parseSecretReference(ref.Key, provider.defaultVault)
+	if err != nil {
+		return nil, err
+	}
+	if err := provider.checkRefVault(ctx, secretRef); err != nil {
+		return nil, err
+	}
+	property, attribute := secretRef.field, secretRef.attribute
+	if property == "" {
+		property, attribute, err = splitAttribute(ref.Property)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if secretRef.field == "" || ref.Version != "" || attribute != "" || property == jsonProperty || !secretRef.resolvable() {
+		return provider.getItemFieldValue(ctx, secretRef, ref.Version, property, attribute)
+	}
+
+	secret, err := provider.client.Secrets.Resolve(ctx, secretRef.String())
+	if err != nil && (strings.Contains(err.Error(), sdkAmbiguousField) || errors.Is(mapError(err), ErrSecretNotFound)) {
+		// read the item to match field IDs before labels, and to tell which of the item, a field
+		// ID or a field label is missing
+		return provider.getItemFieldValue(ctx, secretRef, ref.Version, property, attribute)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(secret, otpauthScheme) {
+		// a one-time password field resolves to its seed, read the item for its current code
+		return provider.getItemFieldValue(ctx, secretRef, ref.Version, property, attribute)
+	}
 	return []byte(secret), nil
 }
 
-// Close closes the client connection.
-func (provider *ProviderOnePasswordSdk) Close(_ context.Context) error {
+// getItemFieldValue reads the field named property, within the section of ref if any, out of the
+// item at the given version.
+func (provider *ProviderOnePasswordSdk) getItemFieldValue(ctx context.Context, ref secretReference, version, property, attribute string) ([]byte, error) {
+	vault, item, err := provider.findItem(ctx, ref.vault, ref.item)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkItemVersion(item, version); err != nil {
+		return nil, err
+	}
+	if item.Category == onepassword.ItemCategorySSHKey && ref.section == "" && attribute == "" {
+		if property == "" || property == sshKeyPrivateKey {
+			return provider.resolveSSHPrivateKey(ctx, item)
+		}
+	}
+	if property == "" {
+		return nil, fmt.Errorf(errExpectedFieldRef, ref.String())
+	}
+	if ref.section == "" && attribute == "" && isNotesProperty(item, property) {
+		return provider.resolveItemField(ctx, item, notesPlain)
+	}
+	if ref.section == "" && attribute == "" && isURLProperty(item, property) {
+		return loginURL(item)
+	}
+	if ref.section == "" && attribute == "" && isJSONProperty(item, property) {
+		return provider.itemToJSON(ctx, vault, item)
+	}
+	if attribute == "" && strings.Contains(property, propertyListSep) {
+		return itemFieldsValue(item, ref.section, property)
+	}
+	return itemFieldValue(item, ref.section, property, attribute)
+}
+
+// itemFieldsValue returns the fields of a comma separated list of labels or IDs as a JSON object
+// keyed by the labels as listed, failing with every label that is missing. A field whose label
+// itself has a comma is read on its own, as before lists were supported.
+func itemFieldsValue(item *onepassword.Item, section, property string) ([]byte, error) {
+	if value, err := itemFieldValue(item, section, property, ""); err == nil {
+		return value, nil
+	}
+	fields := make(map[string][]byte)
+	var missing []string
+	for _, label := range strings.Split(property, propertyListSep) {
+		label = strings.TrimSpace(label)
+		if label == "" {
+			return nil, fmt.Errorf(errEmptyPropertyList, property)
+		}
+		value, err := itemFieldValue(item, section, label, "")
+		if errors.Is(err, ErrSecretNotFound) {
+			missing = append(missing, strconv.Quote(label))
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		fields[label] = value
+	}
+	if len(missing) > 0 {
+		return nil, newTypedError(ErrSecretNotFound, fmt.Errorf(errFieldsNotFound, strings.Join(missing, ", "), item.Title))
+	}
+	return marshalFields(fields)
+}
+
+// resolveSSHPrivateKey returns the private key of an SSH key item. The SDK does not model the
+// private key field of an item, only resolving a secret reference to it returns its value.
+func (provider *ProviderOnePasswordSdk) resolveSSHPrivateKey(ctx context.Context, item *onepassword.Item) ([]byte, error) {
+	return provider.resolveItemField(ctx, item, sshKeyPrivateKey)
+}
+
+// resolveItemField returns the value of the field of the item with the given ID by resolving a
+// secret reference to it, for the fields the SDK leaves out of the item.
+func (provider *ProviderOnePasswordSdk) resolveItemField(ctx context.Context, item *onepassword.Item, fieldID string) ([]byte, error) {
+	ref := secretReference{vault: item.VaultID, item: item.ID, field: fieldID}
+	secret, err := provider.client.Secrets.Resolve(ctx, ref.String())
+	if err != nil {
+		return nil, err
+	}
+	return []byte(secret), nil
+}
+
+// isNotesProperty reports whether property reads the notes of the item, such as the body of a
+// Secure Note: notesPlain always does, notes does unless the item has a field labeled notes.
+func isNotesProperty(item *onepassword.Item, property string) bool {
+	if property == notesPlain {
+		return true
+	}
+	if property != notesProperty {
+		return false
+	}
+	_, err := itemFieldValue(item, "", property, "")
+	return errors.Is(err, ErrSecretNotFound)
+}
+
+// isURLProperty reports whether property reads the website of a Login item: url does unless the
+// item has a field labeled url.
+func isURLProperty(item *onepassword.Item, property string) bool {
+	if property != urlProperty || item.Category != onepassword.ItemCategoryLogin {
+		return false
+	}
+	_, err := itemFieldValue(item, "", property, "")
+	return errors.Is(err, ErrSecretNotFound)
+}
+
+// loginURL returns the website of a Login item, the value of its first URL field.
+func loginURL(item *onepassword.Item) ([]byte, error) {
+	for _, field := range item.Fields {
+		if field.FieldType == onepassword.ItemFieldTypeURL {
+			return []byte(field.Value), nil
+		}
+	}
+	return nil, newTypedError(ErrSecretNotFound, fmt.Errorf(errNoWebsite, item.Title))
+}
+
+// addNotes adds the notes of the item to secretData under notesPlain, unless it has none.
+func (provider *ProviderOnePasswordSdk) addNotes(ctx context.Context, item *onepassword.Item, secretData map[string][]byte) error {
+	notes, err := provider.resolveItemField(ctx, item, notesPlain)
+	if errors.Is(mapError(err), ErrSecretNotFound) || (err == nil && len(notes) == 0) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, ok := secretData[notesPlain]; ok {
+		return fmt.Errorf(errExpectedOneField, notesPlain, item.Title)
+	}
+	secretData[notesPlain] = notes
 	return nil
 }
 
-// DeleteSecret Not Implemented
-func (provider *ProviderOnePasswordSdk) DeleteSecret(ctx context.Context, remoteRef esv1beta1.PushSecretRemoteRef) error {
-	return fmt.Errorf(errOnePasswordSdkStore, errors.New(errNotImplemented))
+// sshKeyToMap returns the private key, public key and fingerprint of an SSH key item under
+// their field IDs, which unlike labels do not change with the language of the 1Password app.
+func (provider *ProviderOnePasswordSdk) sshKeyToMap(ctx context.Context, item *onepassword.Item) (map[string][]byte, error) {
+	privateKey, err := provider.resolveSSHPrivateKey(ctx, item)
+	if err != nil {
+		return nil, err
+	}
+	secretData := map[string][]byte{sshKeyPrivateKey: privateKey}
+	for _, id := range []string{sshKeyPublicKey, sshKeyFingerprint} {
+		value, err := itemFieldValue(item, "", id, "")
+		if err != nil {
+			return nil, err
+		}
+		secretData[id] = value
+	}
+	return secretData, nil
 }
 
-// GetAllSecrets implements v1beta1.SecretsClient.
-func (provider *ProviderOnePasswordSdk) GetAllSecrets(ctx context.Context, ref esv1beta1.ExternalSecretFind) (map[string][]byte, error) {
-	panic("unimplemented")
+// Close drops the SDK client, which the SDK releases once garbage collected as it has no
+// method to release it right away. Any call made after Close fails.
+func (provider *ProviderOnePasswordSdk) Close(_ context.Context) error {
+	provider.closed = true
+	provider.revalidation.stop()
+	provider.revalidation.wait()
+	provider.sdkClient = nil
+	provider.client = onepassword.Client{}
+	provider.connect = nil
+	// the cache is shared with the other clients of the store and stays in place for them
+	provider.cache = nil
+	return nil
 }
 
-// GetSecretMap implements v1beta1.SecretsClient.
+// GetSecretMap returns the fields of the item referenced as op://<vault>/<item>, keyed by label,
+// or the metadata of the item when remoteRef.metadataPolicy is Fetch.
 func (provider *ProviderOnePasswordSdk) GetSecretMap(ctx context.Context, ref esv1beta1.ExternalSecretDataRemoteRef) (map[string][]byte, error) {
-	panic("unimplemented")
+	if err := provider.checkReadable(); err != nil {
+		return nil, err
+	}
+	ctx = withOperation(ctx, "GetSecretMap", "reference", provider.redact.reference(ref.Key))
+	if secretMap, ok := provider.cache.getSecretMap(ref); ok {
+		return secretMap, nil
+	}
+	ctx, cancel := provider.withTimeout(ctx)
+	defer cancel()
+	secretMap, err := reauth(ctx, provider, func() (map[string][]byte, error) {
+		return retry(ctx, provider.retrier, func() (map[string][]byte, error) {
+			return provider.getSecretMap(ctx, ref)
+		})
+	})
+	if err != nil {
+		return nil, provider.redact.err(mapError(err))
+	}
+	provider.cache.addSecretMap(ref, secretMap)
+	return secretMap, nil
+}
+
+func (provider *ProviderOnePasswordSdk) getSecretMap(ctx context.Context, ref esv1beta1.ExternalSecretDataRemoteRef) (map[string][]byte, error) {
+	if err := checkVersion(ref.Version); err != nil {
+		return nil, err
+	}
+	itemRef, err := parseItemReference(ref.Key, provider.defaultVault)
+	if err != nil {
+		return nil, err
+	}
+	if err := provider.checkRefVault(ctx, itemRef); err != nil {
+		return nil, err
+	}
+	// a single Items.Get returns every field along with its value, so unlike resolving
+	// op:// references one by one there is nothing left to fetch per field.
+	vault, item, err := provider.findItem(ctx, itemRef.vault, itemRef.item)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkItemVersion(item, ref.Version); err != nil {
+		return nil, err
+	}
+	if ref.MetadataPolicy == esv1beta1.ExternalSecretMetadataPolicyFetch {
+		return fetchMetadata(vault, item), nil
+	}
+	if item.Category == onepassword.ItemCategoryDocument {
+		return nil, fmt.Errorf(errDocumentItem, item.Title)
+	}
+	var secretData map[string][]byte
+	if item.Category == onepassword.ItemCategorySSHKey {
+		secretData, err = provider.sshKeyToMap(ctx, item)
+	} else {
+		secretData, err = provider.itemFieldsAndNotesToMap(ctx, item)
+	}
+	if err != nil {
+		return nil, err
+	}
+	conversion, _ := provider.conversionStrategy(ref.ConversionStrategy)
+	filterFields(secretData, provider.includeFields, provider.excludeFields, conversion)
+	if provider.omitEmptyFields {
+		omitEmpty(secretData)
+	}
+	if provider.includeMetadata {
+		if err := addMetadata(vault, item, secretData); err != nil {
+			return nil, err
+		}
+	}
+	// the fields decoded with a strategy of their own are not encoded again when the store default
+	// decodes the others, as the controller then leaves them as they are
+	decoding := ref.DecodingStrategy
+	if _, ok := provider.decodingStrategy(decoding); ok {
+		decoding = esv1beta1.ExternalSecretDecodeNone
+	}
+	if err := decodeFields(item, provider.fieldDecoding, decoding, secretData); err != nil {
+		return nil, err
+	}
+	return provider.applyDefaults(ref, item.Title, secretData)
 }
 
-// PushSecret Not Implemented
-func (provider *ProviderOnePasswordSdk) PushSecret(ctx context.Context, secret *v1.Secret, data esv1beta1.PushSecretData) error {
-	return fmt.Errorf(errOnePasswordSdkStore, errors.New(errNotImplemented))
+// filterFields leaves the keys of secretData that are not in include out of it, unless include
+// is empty, then those in exclude, either as they are or once converted with strategy as the
+// controller does next. The metadata of the item is added afterwards, and is never left out.
+func filterFields(secretData map[string][]byte, include, exclude []string, strategy esv1beta1.ExternalSecretConversionStrategy) {
+	for key := range secretData {
+		if len(include) > 0 && !slices.Contains(include, key) {
+			delete(secretData, key)
+			continue
+		}
+		if slices.Contains(exclude, key) || slices.Contains(exclude, convertKey(strategy, key)) {
+			delete(secretData, key)
+		}
+	}
 }
 
-// SecretExists Not Implemented.
-func (provider *ProviderOnePasswordSdk) SecretExists(ctx context.Context, remoteRef esv1beta1.PushSecretRemoteRef) (bool, error) {
-	return false, fmt.Errorf(errOnePasswordSdkStore, errors.New(errNotImplemented))
+// omitEmpty leaves the keys of secretData without a value, or with only whitespace, out of it.
+// Values are checked as they are stored in 1Password, before they are decoded.
+func omitEmpty(secretData map[string][]byte) {
+	for key, value := range secretData {
+		if len(bytes.TrimSpace(value)) == 0 {
+			delete(secretData, key)
+		}
+	}
 }
 
-// Validate checks if the client is configured correctly
-// currently only checks if it is possible to list vaults
-func (provider *ProviderOnePasswordSdk) Validate() (esv1beta1.ValidationResult, error) {
-	// TODO: maybe try to list a secret
-	// although this may not be ideal, since by getting a secret the an entry is added to the audit log
-	// this may confuse users, as they don't know why this entry is getting revealed
-	vaults, err := provider.client.Vaults.ListAll(context.TODO())
+// itemFieldsAndNotesToMap is itemFieldsToMap, with the field map of the store, along with the
+// username and password of a Login item and the notes of the item.
+func (provider *ProviderOnePasswordSdk) itemFieldsAndNotesToMap(ctx context.Context, item *onepassword.Item) (map[string][]byte, error) {
+	secretData, err := itemFieldsToMap(item, provider.fieldMap)
 	if err != nil {
-		return esv1beta1.ValidationResultError, err
+		return nil, err
 	}
-	_, err = vaults.Next()
+	if err := addLoginFields(item, provider.fieldMap, secretData); err != nil {
+		return nil, err
+	}
+	if err := provider.addNotes(ctx, item, secretData); err != nil {
+		return nil, err
+	}
+	return secretData, nil
+}
+
+// addMetadata adds the metadata of the item to its fields under keys prefixed with
+// metadataPrefix, failing when a field already uses the prefix rather than overwriting either.
+func addMetadata(vault *onepassword.VaultOverview, item *onepassword.Item, secretData map[string][]byte) error {
+	for key := range secretData {
+		if strings.HasPrefix(key, metadataPrefix) {
+			return fmt.Errorf(errMetadataPrefix, key, item.Title, metadataPrefix)
+		}
+	}
+	for key, value := range itemMetadataToMap(vault, item) {
+		secretData[metadataPrefix+key] = value
+	}
+	return nil
+}
+
+// decodeFields decodes the fields of secretData with a decoding strategy of their own in
+// strategies, which takes precedence over the decodingStrategy of the reference. The controller
+// decodes every field with the latter once returned, so the decoded value is encoded again for
+// it: fields without a strategy of their own are left as they are, for the controller to decode.
+func decodeFields(item *onepassword.Item, strategies map[string]esv1beta1.ExternalSecretDecodingStrategy, refStrategy esv1beta1.ExternalSecretDecodingStrategy, secretData map[string][]byte) error {
+	for key, strategy := range strategies {
+		value, ok := secretData[key]
+		if !ok || strategy == refStrategy {
+			continue
+		}
+		decoded, err := utils.Decode(strategy, value)
+		if err != nil {
+			return fmt.Errorf(errDecodeField, key, item.Title, strategy, err)
+		}
+		switch refStrategy {
+		case esv1beta1.ExternalSecretDecodeBase64, esv1beta1.ExternalSecretDecodeAuto:
+			decoded = []byte(base64.StdEncoding.EncodeToString(decoded))
+		case esv1beta1.ExternalSecretDecodeBase64URL:
+			decoded = []byte(base64.URLEncoding.EncodeToString(decoded))
+		}
+		secretData[key] = decoded
+	}
+	return nil
+}
+
+// Validate checks if the client is configured correctly, as selected by the validation strategy
+// of the store. Listing vaults is the default, although it adds vault access to the audit log.
+func (provider *ProviderOnePasswordSdk) Validate() (esv1beta1.ValidationResult, error) {
+	switch provider.validationStrategy {
+	case esv1beta1.OnePasswordSdkValidationNone:
+		return esv1beta1.ValidationResultUnknown, nil
+	case esv1beta1.OnePasswordSdkValidationAuthenticate:
+		ctx, cancel := provider.withTimeout(withOperation(context.Background(), "Validate"))
+		defer cancel()
+		if err := provider.Ping(ctx); err != nil {
+			return esv1beta1.ValidationResultError, err
+		}
+		return esv1beta1.ValidationResultReady, nil
+	}
+
+	ctx, cancel := provider.withTimeout(withOperation(context.Background(), "Validate"))
+	defer cancel()
+	_, err := reauth(ctx, provider, func() ([]onepassword.VaultOverview, error) {
+		return retry(ctx, provider.retrier, func() ([]onepassword.VaultOverview, error) {
+			vaults, err := provider.listVaults(ctx)
+			if err == nil && len(vaults) == 0 && !provider.allowNoVaults {
+				err = errors.New(errNoVaults)
+			}
+			return vaults, err
+		})
+	})
 	if err != nil {
-		return esv1beta1.ValidationResultError, err
+		return esv1beta1.ValidationResultError, provider.redact.err(mapError(err))
 	}
 	return esv1beta1.ValidationResultReady, nil
 }
 
+// integrationInfo returns the integration name and version reported to 1Password,
+// defaulting to external-secrets and the version it was built as.
+func integrationInfo(config *esv1beta1.OnePasswordSdkProvider) (string, string) {
+	name := config.IntegrationName
+	if name == "" {
+		name = defaultIntegrationName
+	}
+	version := config.IntegrationVersion
+	if version == "" {
+		version = buildVersion()
+	}
+	return name, version
+}
+
+// buildVersion returns the module version external-secrets was built as.
+func buildVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok || info.Main.Version == "" || info.Main.Version == develBuildVersion {
+		return onepassword.DefaultIntegrationVersion
+	}
+	return info.Main.Version
+}
+
+// Ping checks that 1Password is available and accepts the service account token, by signing
+// in again with it. Signing in does not access any vault, so it is cheaper than listing them and
+// does not show up as vault access in the audit log. The error tells 1Password being unavailable
+// apart from the token being rejected.
+func (provider *ProviderOnePasswordSdk) Ping(ctx context.Context) error {
+	if provider.closed || provider.connect == nil {
+		return errors.New(errClientClosed)
+	}
+	// the client signed in with is dropped, the SDK releases it once garbage collected
+	_, err := provider.connect(ctx)
+	switch {
+	case err == nil:
+		return nil
+	case isUnavailable(err):
+		return fmt.Errorf(errUnavailable, err)
+	case isAuthError(err):
+		return tokenRejected(err)
+	default:
+		return err
+	}
+}
+
+// withTimeout bounds ctx by the request timeout of the store, if any.
+func (provider *ProviderOnePasswordSdk) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if provider.requestTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, provider.requestTimeout)
+}
+
+// checkVault rejects vaults outside the allow-list before reading any secret out of them. A
+// vault named as the allow-list lists it is allowed without calling 1Password; any other is
+// looked up, so that its ID and title are both checked.
+func (provider *ProviderOnePasswordSdk) checkVault(ctx context.Context, vault string) error {
+	if len(provider.vaults) == 0 || slices.Contains(provider.vaults, vault) || containsTitle(provider.vaults, vault, provider.strictNames) {
+		return nil
+	}
+	_, err := provider.findVault(ctx, vault)
+	return err
+}
+
+// checkRefVault is checkVault for the vault of ref. The vault of a reference by item ID is only
+// known once the item is found, and checked then.
+func (provider *ProviderOnePasswordSdk) checkRefVault(ctx context.Context, ref secretReference) error {
+	if ref.byItemID() {
+		return nil
+	}
+	return provider.checkVault(ctx, ref.vault)
+}
+
+// vaultAllowed reports whether a listed vault is in the allow-list, by title or ID.
+func (provider *ProviderOnePasswordSdk) vaultAllowed(vault *onepassword.VaultOverview) bool {
+	return len(provider.vaults) == 0 ||
+		slices.Contains(provider.vaults, vault.ID) ||
+		containsTitle(provider.vaults, vault.Title, provider.strictNames)
+}
+
+// allowedVault returns vault, or ErrPermissionDenied when it is outside the allow-list.
+func (provider *ProviderOnePasswordSdk) allowedVault(vault *onepassword.VaultOverview) (*onepassword.VaultOverview, error) {
+	if !provider.vaultAllowed(vault) {
+		return nil, newTypedError(ErrPermissionDenied, fmt.Errorf(errVaultNotAllowed, vault.Title))
+	}
+	return vault, nil
+}
+
+// sameTitle reports whether two vault or item titles match, ignoring case unless strict, as set by
+// strictNameMatching. IDs are always matched exactly.
+func sameTitle(a, b string, strict bool) bool {
+	if strict {
+		return a == b
+	}
+	return strings.EqualFold(a, b)
+}
+
+// containsTitle reports whether title matches one of titles, as sameTitle does.
+func containsTitle(titles []string, title string, strict bool) bool {
+	return slices.ContainsFunc(titles, func(t string) bool {
+		return sameTitle(t, title, strict)
+	})
+}
+
+// findVault returns the vault whose title or ID equals name. Unless strictNameMatching is set,
+// a single vault whose title only matches ignoring case is returned as well. A vault outside the
+// allow-list fails with ErrPermissionDenied, whether it was named by title or by ID.
+func (provider *ProviderOnePasswordSdk) findVault(ctx context.Context, name string) (*onepassword.VaultOverview, error) {
+	vaults, err := provider.listVaults(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var matches []int
+	for i := range vaults {
+		if vaults[i].ID == name || vaults[i].Title == name {
+			// the list is shared through the cache, hand out a copy
+			vault := vaults[i]
+			return provider.allowedVault(&vault)
+		}
+		if sameTitle(vaults[i].Title, name, provider.strictNames) {
+			matches = append(matches, i)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, newTypedError(ErrVaultNotFound, fmt.Errorf(errVaultNotFound, name))
+	case 1:
+		vault := vaults[matches[0]]
+		return provider.allowedVault(&vault)
+	default:
+		titles := make([]string, 0, len(matches))
+		for _, i := range matches {
+			titles = append(titles, strconv.Quote(vaults[i].Title))
+		}
+		return nil, fmt.Errorf(errAmbiguousVaultName, name, strings.Join(titles, ", "))
+	}
+}
+
+// listVaults returns every vault the service account can access. The list is cached for
+// vaultCacheTTL, so that the lookups of a reconcile do not all list the vaults again. With
+// vaultCacheStaleWhileRevalidate, an expired list is still returned while it is refreshed in the
+// background.
+func (provider *ProviderOnePasswordSdk) listVaults(ctx context.Context) ([]onepassword.VaultOverview, error) {
+	if provider.revalidateVaults {
+		if vaults, fresh, ok := provider.vaultList.getStale(vaultListKey); ok {
+			if !fresh {
+				provider.revalidateVaultList(ctx)
+			}
+			return vaults, nil
+		}
+	} else if vaults, ok := provider.vaultList.get(vaultListKey); ok {
+		return vaults, nil
+	}
+	vaults, err := fetchVaults(ctx, provider.client.Vaults)
+	if err != nil {
+		return nil, err
+	}
+	provider.vaultList.add(vaultListKey, vaults)
+	return vaults, nil
+}
+
+// revalidateVaultList lists the vaults again in the background, unless that is already underway.
+// The refresh outlives the call it is started by, up to Close, and is bounded by requestTimeout.
+func (provider *ProviderOnePasswordSdk) revalidateVaultList(ctx context.Context) {
+	// the client is replaced when signing in again, the refresh keeps to the one it started with
+	vaultsAPI := provider.client.Vaults
+	provider.revalidation.start(context.WithoutCancel(ctx), func(ctx context.Context) {
+		ctx, cancel := provider.withTimeout(ctx)
+		defer cancel()
+		vaults, err := fetchVaults(ctx, vaultsAPI)
+		if err != nil {
+			loggerFrom(ctx).V(1).Info("failed to refresh the 1Password vault list", "error", provider.redact.message(err.Error()))
+			return
+		}
+		if ctx.Err() == nil {
+			provider.vaultList.add(vaultListKey, vaults)
+		}
+	})
+}
+
+func fetchVaults(ctx context.Context, vaultsAPI onepassword.VaultsAPI) ([]onepassword.VaultOverview, error) {
+	it, err := vaultsAPI.ListAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf(errListVaults, err)
+	}
+	var vaults []onepassword.VaultOverview
+	err = forEach(it, func(vault *onepassword.VaultOverview) error {
+		vaults = append(vaults, *vault)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf(errListVaults, err)
+	}
+	return vaults, nil
+}
+
+// findItem returns the full item whose title or ID equals itemName inside the vault vaultName,
+// along with the vault. In a reference by item ID, the item is looked up in every vault.
+func (provider *ProviderOnePasswordSdk) findItem(ctx context.Context, vaultName, itemName string) (*onepassword.VaultOverview, *onepassword.Item, error) {
+	vault, err := provider.findVault(ctx, vaultName)
+	if isItemIDReference(vaultName, itemName) {
+		if errors.Is(err, ErrVaultNotFound) {
+			return provider.findItemByID(ctx, itemName)
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	itemID, err := provider.findItemID(ctx, vault, itemName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if itemID == "" {
+		return nil, nil, newTypedError(ErrSecretNotFound, fmt.Errorf(errItemNotFound, itemName, vaultName))
+	}
+
+	item, err := provider.client.Items.Get(ctx, vault.ID, itemID)
+	if err != nil {
+		return nil, nil, fmt.Errorf(errGetItem, err)
+	}
+	return vault, &item, nil
+}
+
+// findItemByID returns the item with the given ID out of the first of the allowed vaults, in
+// order of vault ID, holding it. Item IDs are unique, the order only keeps the lookup stable.
+func (provider *ProviderOnePasswordSdk) findItemByID(ctx context.Context, itemID string) (*onepassword.VaultOverview, *onepassword.Item, error) {
+	vaults, err := provider.listVaults(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	vaults = slices.DeleteFunc(slices.Clone(vaults), func(vault onepassword.VaultOverview) bool {
+		return !provider.vaultAllowed(&vault)
+	})
+	slices.SortFunc(vaults, func(a, b onepassword.VaultOverview) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	for i := range vaults {
+		item, err := provider.client.Items.Get(ctx, vaults[i].ID, itemID)
+		if errors.Is(mapError(err), ErrSecretNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf(errGetItem, err)
+		}
+		return &vaults[i], &item, nil
+	}
+	return nil, nil, newTypedError(ErrSecretNotFound, fmt.Errorf(errItemIDNotFound, itemID))
+}
+
+// findItemID returns the ID of the item whose title or ID equals itemName inside vault,
+// or an empty string when nothing matches. An exact ID match wins; a title must match
+// exactly one item. Unless strictNameMatching is set, a title matching no item exactly may match
+// a single item ignoring case.
+func (provider *ProviderOnePasswordSdk) findItemID(ctx context.Context, vault *onepassword.VaultOverview, itemName string) (string, error) {
+	items, err := provider.client.Items.ListAll(ctx, vault.ID)
+	if err != nil {
+		return "", fmt.Errorf(errListItems, err)
+	}
+
+	var (
+		matches      []string
+		foldMatches  []string
+		foldedTitles []string
+	)
+	for {
+		overview, err := items.Next()
+		if errors.Is(err, onepassword.ErrorIteratorDone) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf(errListItems, err)
+		}
+		if overview.ID == itemName {
+			return overview.ID, nil
+		}
+		if overview.Title == itemName {
+			matches = append(matches, overview.ID)
+		} else if sameTitle(overview.Title, itemName, provider.strictNames) {
+			foldMatches = append(foldMatches, overview.ID)
+			foldedTitles = append(foldedTitles, strconv.Quote(overview.Title))
+		}
+	}
+
+	switch {
+	case len(matches) == 1:
+		return matches[0], nil
+	case len(matches) > 1:
+		return "", fmt.Errorf(errExpectedOneItem, itemName, vault.Title, len(matches))
+	case len(foldMatches) == 1:
+		return foldMatches[0], nil
+	case len(foldMatches) > 1:
+		return "", fmt.Errorf(errAmbiguousItemName, itemName, vault.Title, strings.Join(foldedTitles, ", "))
+	default:
+		return "", nil
+	}
+}
+
+// checkVersion rejects the versions relative to the current version of an item, which the SDK
+// cannot read, before anything is read from 1Password.
+func checkVersion(version string) error {
+	if version == previousVersion || version == previousVersionOffset {
+		return fmt.Errorf(errRelativeVersion, version)
+	}
+	return nil
+}
+
+// checkItemVersion checks that version, when set, is the version of item. The SDK only reads
+// the current version of an item and has no access to its history, so that is the only
+// version that can be served. Any other version fails with an error that is not
+// ErrSecretNotFound, as the item still exists and its keys must not be deleted.
+func checkItemVersion(item *onepassword.Item, version string) error {
+	if version == "" || version == strconv.FormatUint(uint64(item.Version), 10) {
+		return nil
+	}
+	return fmt.Errorf(errVersionNotFound, version, item.Title, item.Version)
+}
+
+// itemFieldValue returns the value of the field whose ID or label equals property, only looking
+// at the fields of section when set. An exact ID match wins; a label must match exactly one field.
+func itemFieldValue(item *onepassword.Item, section, property, attribute string) ([]byte, error) {
+	fields := item.Fields
+	if section != "" {
+		sectionID, err := findSectionID(item, section)
+		if err != nil {
+			return nil, err
+		}
+		fields = slices.DeleteFunc(slices.Clone(fields), func(field onepassword.ItemField) bool {
+			return field.SectionID == nil || *field.SectionID != sectionID
+		})
+	}
+
+	var (
+		matches []onepassword.ItemField
+		labels  = make([]string, 0, len(fields))
+		ids     = make([]string, 0, len(fields))
+	)
+	// IDs are matched first, as they are stable while labels can be renamed
+	for _, field := range fields {
+		if field.ID == property {
+			return fieldValue(item, field, attribute)
+		}
+		if field.Title == property {
+			matches = append(matches, field)
+		}
+		labels = append(labels, fieldKey(field))
+		ids = append(ids, field.ID)
+	}
+
+	switch len(matches) {
+	case 0:
+		if item.Category == onepassword.ItemCategoryDocument {
+			return nil, fmt.Errorf(errDocumentItem, item.Title)
+		}
+		return nil, newTypedError(ErrSecretNotFound, fmt.Errorf(errFieldNotFound, property, item.Title, strings.Join(labels, ", "), strings.Join(ids, ", ")))
+	case 1:
+		return fieldValue(item, matches[0], attribute)
+	default:
+		if sections := fieldSections(item, matches); len(sections) > 0 {
+			return nil, fmt.Errorf(errAmbiguousField, property, item.Title, strings.Join(sections, ", "))
+		}
+		return nil, fmt.Errorf(errExpectedOneField, property, item.Title)
+	}
+}
+
+// findSectionID returns the ID of the section of item whose ID or title equals section.
+func findSectionID(item *onepassword.Item, section string) (string, error) {
+	var matches []string
+	for _, s := range item.Sections {
+		if s.ID == section {
+			return s.ID, nil
+		}
+		if s.Title == section {
+			matches = append(matches, s.ID)
+		}
+	}
+	if len(matches) != 1 {
+		return "", newTypedError(ErrSecretNotFound, fmt.Errorf(errSectionNotFound, section, item.Title))
+	}
+	return matches[0], nil
+}
+
+// fieldSections returns the titles, or IDs when untitled, of the sections the fields are in.
+func fieldSections(item *onepassword.Item, fields []onepassword.ItemField) []string {
+	var sections []string
+	for _, field := range fields {
+		if field.SectionID == nil {
+			continue
+		}
+		for _, s := range item.Sections {
+			if s.ID != *field.SectionID {
+				continue
+			}
+			if s.Title == "" {
+				sections = append(sections, s.ID)
+			} else {
+				sections = append(sections, s.Title)
+			}
+		}
+	}
+	return sections
+}
+
+// fieldValue returns the value of field selected by attribute. One-time password fields
+// return their current code unless their seed is asked for.
+func fieldValue(item *onepassword.Item, field onepassword.ItemField, attribute string) ([]byte, error) {
+	isTOTP := field.FieldType == onepassword.ItemFieldTypeTOTP
+	if attribute != "" && !isTOTP {
+		return nil, fmt.Errorf(errNotTOTPField, fieldKey(field), item.Title)
+	}
+	if !isTOTP || attribute == attributeSeed {
+		return []byte(field.Value), nil
+	}
+
+	var details *onepassword.OTPFieldDetails
+	if field.Details != nil {
+		details = field.Details.OTP()
+	}
+	switch {
+	case details != nil && details.Code != nil:
+		return []byte(*details.Code), nil
+	case details != nil && details.ErrorMessage != nil:
+		return nil, fmt.Errorf(errTOTPCode, fieldKey(field), *details.ErrorMessage)
+	default:
+		return nil, fmt.Errorf(errTOTPCode, fieldKey(field), "no code returned")
+	}
+}
+
+// fieldKey returns the label of a field, falling back to its ID when the label is empty.
+func fieldKey(field onepassword.ItemField) string {
+	if field.Title == "" {
+		return field.ID
+	}
+	return field.Title
+}
+
+// itemFieldsToMap maps every field of the item by its label, falling back to the field ID
+// when the label is empty. Sections are not fields in the SDK model, so only fields
+// carrying a value end up in the map. Fields whose ID is in fieldMap, keyed by the key to return
+// them under, are mapped by that key instead of their label.
+func itemFieldsToMap(item *onepassword.Item, fieldMap map[string]string) (map[string][]byte, error) {
+	mappedKeys := make(map[string][]string, len(fieldMap))
+	for key, fieldID := range fieldMap {
+		mappedKeys[fieldID] = append(mappedKeys[fieldID], key)
+	}
+	secretData := make(map[string][]byte, len(item.Fields))
+	for _, field := range item.Fields {
+		if field.FieldType == onepassword.ItemFieldTypeUnsupported {
+			continue
+		}
+		if keys, ok := mappedKeys[field.ID]; ok {
+			for _, key := range keys {
+				if _, ok := secretData[key]; ok {
+					return nil, fmt.Errorf(errFieldMapKey, key, item.Title)
+				}
+				secretData[key] = []byte(field.Value)
+			}
+			continue
+		}
+		key := fieldKey(field)
+		if _, ok := secretData[key]; ok {
+			if _, mapped := fieldMap[key]; mapped {
+				return nil, fmt.Errorf(errFieldMapKey, key, item.Title)
+			}
+			return nil, fmt.Errorf(errExpectedOneField, key, item.Title)
+		}
+		secretData[key] = []byte(field.Value)
+	}
+
+	return secretData, nil
+}
+
+// addLoginFields adds the built-in username and password fields of a Login item under the
+// username and password keys, along with their label, so that the keys do not change with the
+// language of the 1Password app that created the item. The keys of fieldMap are left as mapped,
+// and a custom field labeled username or password fails rather than be overwritten.
+func addLoginFields(item *onepassword.Item, fieldMap map[string]string, secretData map[string][]byte) error {
+	if item.Category != onepassword.ItemCategoryLogin {
+		return nil
+	}
+	for _, field := range item.Fields {
+		if field.SectionID != nil || (field.ID != loginUsername && field.ID != loginPassword) {
+			continue
+		}
+		if _, mapped := fieldMap[field.ID]; mapped {
+			continue
+		}
+		if _, ok := secretData[field.ID]; ok && fieldKey(field) != field.ID {
+			return fmt.Errorf(errLoginKey, field.ID, item.Title, field.ID)
+		}
+		secretData[field.ID] = []byte(field.Value)
+	}
+	return nil
+}
+
+// fetchMetadata returns the metadata of the item for metadataPolicy Fetch, under the keys
+// prefixed with metadataPrefix as includeMetadata adds them, and under the bare keys too, which
+// were the only ones before.
+func fetchMetadata(vault *onepassword.VaultOverview, item *onepassword.Item) map[string][]byte {
+	metadata := itemMetadataToMap(vault, item)
+	for key, value := range maps.Clone(metadata) {
+		metadata[metadataPrefix+key] = value
+	}
+	return metadata
+}
+
+// itemMetadataToMap returns the metadata of the item: its ID, under both id and item_id, title,
+// category (such as Login or ApiCredentials), vault title, comma separated tags and version, and
+// the website of a Login item that has one. The SDK, as of v0.1.2, does not expose when an item
+// was created or updated: its version, raised by every change, is what tells whether it changed.
+func itemMetadataToMap(vault *onepassword.VaultOverview, item *onepassword.Item) map[string][]byte {
+	metadata := map[string][]byte{
+		metadataID:       []byte(item.ID),
+		metadataItemID:   []byte(item.ID),
+		metadataTitle:    []byte(item.Title),
+		metadataCategory: []byte(item.Category),
+		metadataVault:    []byte(vault.Title),
+		metadataTags:     []byte(strings.Join(item.Tags, metadataTagsSeparator)),
+		metadataVersion:  []byte(strconv.FormatUint(uint64(item.Version), 10)),
+	}
+	if item.Category == onepassword.ItemCategoryLogin {
+		if url, err := loginURL(item); err == nil {
+			metadata[metadataURL] = url
+		}
+	}
+	return metadata
+}
+
 func init() {
 	esv1beta1.Register(&ProviderOnePasswordSdk{}, &esv1beta1.SecretStoreProvider{
 		OnePasswordSdk: &esv1beta1.OnePasswordSdkProvider{},
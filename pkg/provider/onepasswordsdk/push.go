@@ -0,0 +1,622 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/1password/onepassword-sdk-go"
+	corev1 "k8s.io/api/core/v1"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+)
+
+const (
+	errCreateItem          = "error creating 1Password Item: %w"
+	errUpdateItem          = "error updating 1Password Item: %w"
+	errFieldNotWritable    = "1Password ItemField %q of type %s cannot be overwritten"
+	errDeleteItem          = "error deleting 1Password Item: %w"
+	errDeleteTaggedItem    = "error deleting 1Password Item %q: %w"
+	errDeleteTagRequired   = "a tag is required to delete 1Password Items by tag"
+	errDeleteVaultRequired = "a vault is required to delete 1Password Items by tag"
+	errSecretKeyNotFound   = "key %q not found in Secret %q"
+	errSecretHasNoData     = "Secret %q has no data to push"
+	errBlankItemTitle      = "blank 1Password Item title in %q, expected op://<vault>/<item>"
+	errItemVersionConflict = "1Password Item %q is at version %d, expected version %d: it was modified since"
+	errDeletionProtected   = "1Password Item %q is tagged %q, which spec.provider.onepasswordsdk.deletionProtectionTag protects from deletion"
+	errMarkerField         = "Secret %q is pushed to the 1Password ItemField %q, which spec.provider.onepasswordsdk.managedMarker.sourceField sets"
+	defaultPushedCategory  = onepassword.ItemCategoryAPICredentials
+
+	// itemIDTTL bounds how long an item ID looked up by title is reused by the same client.
+	itemIDTTL = 30 * time.Second
+)
+
+// PushSecret writes the Secret into the item referenced by op://<vault>/<item>, creating the
+// item when it does not exist yet. The item title or ID comes from remoteRef.remoteKey, never from
+// the name of the Secret, and an item already titled so is updated: PushSecrets with
+// updatePolicy IfNotExists leave it alone, as SecretExists reports it. When data has a secret key
+// only that key is pushed, into a field labeled after the property (or the key itself). A property
+// without a secret key pushes the key of the same name. Otherwise every key becomes a field.
+// Fields of an existing item are updated in place and fields not pushed are left untouched.
+// The category and tags of the item can be set with a PushSecretMetadata, as can the version an
+// existing item is expected at: the update is then refused with ErrConflict once it moved on.
+// With managedMarker, the item is stamped with its tag and the source of the Secret.
+// With dryRun, the changes are logged instead of written.
+func (provider *ProviderOnePasswordSdk) PushSecret(ctx context.Context, secret *corev1.Secret, data esv1beta1.PushSecretData) error {
+	ctx = withOperation(ctx, "PushSecret", "reference", provider.redact.reference(data.GetRemoteKey()))
+	ctx, cancel := provider.withTimeout(ctx)
+	defer cancel()
+	_, err := reauth(ctx, provider, func() (struct{}, error) {
+		return struct{}{}, provider.pushSecret(ctx, secret, data)
+	})
+	return provider.redact.err(mapError(err))
+}
+
+func (provider *ProviderOnePasswordSdk) pushSecret(ctx context.Context, secret *corev1.Secret, data esv1beta1.PushSecretData) error {
+	ref, err := parseItemReference(data.GetRemoteKey(), provider.defaultVault)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(ref.item) == "" {
+		return fmt.Errorf(errBlankItemTitle, data.GetRemoteKey())
+	}
+	metadata, err := parsePushMetadata(data.GetMetadata())
+	if err != nil {
+		return err
+	}
+	fields, err := pushFields(secret, data, metadata.FieldTypes)
+	if err != nil {
+		return err
+	}
+	if err := provider.checkMarkerField(secret, fields); err != nil {
+		return err
+	}
+
+	vault, err := provider.findVault(ctx, ref.vault)
+	if err != nil {
+		return err
+	}
+	itemID, err := provider.resolveItemID(ctx, vault, ref.item)
+	if err != nil {
+		return err
+	}
+	if itemID != "" {
+		return provider.updateItem(ctx, vault.ID, itemID, secret, fields, metadata)
+	}
+
+	params := onepassword.ItemCreateParams{
+		Category: metadata.Category,
+		VaultID:  vault.ID,
+		Title:    ref.item,
+		Fields:   fields,
+		Tags:     provider.markTags(metadata.Tags, nil),
+	}
+	if metadata.Section != nil {
+		section := newSection(*metadata.Section)
+		params.Sections = []onepassword.ItemSection{section}
+		params.Fields = inSection(fields, section.ID)
+	}
+	params.Fields = provider.markFields(secret, params.Fields)
+	if provider.dryRun {
+		loggerFrom(ctx).Info("dry run: would create 1Password item", "vault", provider.redact.name(vault.Title), "item", provider.redact.name(ref.item), "fields", provider.redact.names(fieldLabels(params.Fields)))
+		return nil
+	}
+	item, err := provider.client.Items.Create(ctx, params)
+	if err != nil {
+		return fmt.Errorf(errCreateItem, err)
+	}
+	provider.itemIDs.add(itemIDCacheKey(vault, ref.item), item.ID)
+	return nil
+}
+
+// SecretExists reports whether the item referenced by op://<vault>/<item>, or its field named by
+// op://<vault>/<item>/[<section>/]<field> or the property, exists. A vault the service account cannot see is reported as an error rather
+// than as a missing secret, since it points at missing permissions more often than not.
+func (provider *ProviderOnePasswordSdk) SecretExists(ctx context.Context, remoteRef esv1beta1.PushSecretRemoteRef) (bool, error) {
+	ctx = withOperation(ctx, "SecretExists", "reference", provider.redact.reference(remoteRef.GetRemoteKey()))
+	ctx, cancel := provider.withTimeout(ctx)
+	defer cancel()
+	exists, err := reauth(ctx, provider, func() (bool, error) {
+		return provider.secretExists(ctx, remoteRef)
+	})
+	return exists, provider.redact.err(mapError(err))
+}
+
+func (provider *ProviderOnePasswordSdk) secretExists(ctx context.Context, remoteRef esv1beta1.PushSecretRemoteRef) (bool, error) {
+	ref, err := parseSecretReference(remoteRef.GetRemoteKey(), provider.defaultVault)
+	if err != nil {
+		return false, err
+	}
+	vault, err := provider.findVault(ctx, ref.vault)
+	if err != nil {
+		return false, err
+	}
+	itemID, err := provider.resolveItemID(ctx, vault, ref.item)
+	if err != nil {
+		return false, err
+	}
+	if itemID == "" {
+		return false, nil
+	}
+
+	property := ref.field
+	if property == "" {
+		property = remoteRef.GetProperty()
+	}
+	if property == "" {
+		return true, nil
+	}
+	item, err := provider.client.Items.Get(ctx, vault.ID, itemID)
+	if err != nil {
+		return false, fmt.Errorf(errGetItem, err)
+	}
+	matches, err := findFields(&item, ref.section, property)
+	return len(matches) > 0, err
+}
+
+// DeleteSecret deletes the item referenced by op://<vault>/<item>, or only its field named by
+// op://<vault>/<item>/<field> or the property, leaving the item itself in place, like
+// SecretExists reads them. A label shared by several fields must be qualified by its section, as in
+// op://<vault>/<item>/<section>/<field>. Deleting something that does not exist is a no-op.
+// An item tagged with deletionProtectionTag is not deleted: ErrDeletionProtected is returned.
+// With dryRun, the deletion is logged instead.
+func (provider *ProviderOnePasswordSdk) DeleteSecret(ctx context.Context, remoteRef esv1beta1.PushSecretRemoteRef) error {
+	ctx = withOperation(ctx, "DeleteSecret", "reference", provider.redact.reference(remoteRef.GetRemoteKey()))
+	ctx, cancel := provider.withTimeout(ctx)
+	defer cancel()
+	_, err := reauth(ctx, provider, func() (struct{}, error) {
+		return struct{}{}, provider.deleteSecret(ctx, remoteRef)
+	})
+	return provider.redact.err(mapError(err))
+}
+
+func (provider *ProviderOnePasswordSdk) deleteSecret(ctx context.Context, remoteRef esv1beta1.PushSecretRemoteRef) error {
+	ref, err := parseSecretReference(remoteRef.GetRemoteKey(), provider.defaultVault)
+	if err != nil {
+		return err
+	}
+	vault, err := provider.findVault(ctx, ref.vault)
+	if err != nil {
+		return err
+	}
+	itemID, err := provider.resolveItemID(ctx, vault, ref.item)
+	if err != nil {
+		return err
+	}
+	if itemID == "" {
+		return nil
+	}
+
+	property := ref.field
+	if property == "" {
+		property = remoteRef.GetProperty()
+	}
+	if property == "" {
+		if err := provider.checkDeletionProtection(ctx, vault.ID, itemID); err != nil {
+			return err
+		}
+		if provider.dryRun {
+			loggerFrom(ctx).Info("dry run: would delete 1Password item", "vault", provider.redact.name(vault.Title), "item", provider.redact.name(ref.item))
+			return nil
+		}
+		if err := provider.client.Items.Delete(ctx, vault.ID, itemID); err != nil {
+			return fmt.Errorf(errDeleteItem, err)
+		}
+		provider.itemIDs.delete(itemIDCacheKey(vault, ref.item))
+		return nil
+	}
+
+	item, err := provider.client.Items.Get(ctx, vault.ID, itemID)
+	if err != nil {
+		return fmt.Errorf(errGetItem, err)
+	}
+	matches, err := findFields(&item, ref.section, property)
+	if err != nil {
+		return err
+	}
+	switch len(matches) {
+	case 0:
+		return nil
+	case 1:
+	default:
+		// deleting every field of that label is rarely what was meant
+		if sections := fieldSections(&item, fieldsAt(&item, matches)); ref.section == "" && len(sections) > 0 {
+			return fmt.Errorf(errAmbiguousField, property, item.Title, strings.Join(sections, ", "))
+		}
+		return fmt.Errorf(errExpectedOneField, property, item.Title)
+	}
+	if provider.dryRun {
+		loggerFrom(ctx).Info("dry run: would delete 1Password item field", "vault", provider.redact.name(vault.Title), "item", provider.redact.name(item.Title), "field", provider.redact.name(property))
+		return nil
+	}
+	item.Fields = slices.Delete(slices.Clone(item.Fields), matches[0], matches[0]+1)
+	if _, err := provider.client.Items.Put(ctx, item); err != nil {
+		return fmt.Errorf(errUpdateItem, err)
+	}
+	return nil
+}
+
+// findFields returns the indexes in item.Fields of the fields whose ID or label equals property,
+// only looking at the fields of section when set. An exact ID match is the only one returned. A
+// missing section matches no field.
+func findFields(item *onepassword.Item, section, property string) ([]int, error) {
+	var sectionID string
+	if section != "" {
+		id, err := findSectionID(item, section)
+		if errors.Is(err, ErrSecretNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		sectionID = id
+	}
+	var matches []int
+	for i, field := range item.Fields {
+		if section != "" && (field.SectionID == nil || *field.SectionID != sectionID) {
+			continue
+		}
+		if field.ID == property {
+			return []int{i}, nil
+		}
+		if field.Title == property {
+			matches = append(matches, i)
+		}
+	}
+	return matches, nil
+}
+
+// fieldsAt returns the fields of item at indexes.
+func fieldsAt(item *onepassword.Item, indexes []int) []onepassword.ItemField {
+	fields := make([]onepassword.ItemField, 0, len(indexes))
+	for _, i := range indexes {
+		fields = append(fields, item.Fields[i])
+	}
+	return fields
+}
+
+// DeleteSecretsByTag deletes every item of vault, by title or ID, tagged with tag, and returns
+// how many were deleted. The vault is required so that a tag is never matched account-wide. An item
+// failing to be deleted does not stop the others, the errors of all of them are returned together,
+// and so does an item tagged with deletionProtectionTag.
+// With dryRun, the items are logged and counted instead.
+func (provider *ProviderOnePasswordSdk) DeleteSecretsByTag(ctx context.Context, vault, tag string) (int, error) {
+	if vault == "" {
+		return 0, errors.New(errDeleteVaultRequired)
+	}
+	if tag == "" {
+		return 0, errors.New(errDeleteTagRequired)
+	}
+	ctx = withOperation(ctx, "DeleteSecretsByTag", "vault", provider.redact.name(vault), "tag", provider.redact.name(tag))
+	ctx, cancel := provider.withTimeout(ctx)
+	defer cancel()
+	// items deleted before signing in again are counted too
+	var deleted int
+	_, err := reauth(ctx, provider, func() (struct{}, error) {
+		return struct{}{}, provider.deleteSecretsByTag(ctx, vault, tag, &deleted)
+	})
+	return deleted, provider.redact.err(mapError(err))
+}
+
+func (provider *ProviderOnePasswordSdk) deleteSecretsByTag(ctx context.Context, vaultName, tag string, deleted *int) error {
+	vault, err := provider.findVault(ctx, vaultName)
+	if err != nil {
+		return err
+	}
+	items, err := provider.client.Items.ListAll(ctx, vault.ID)
+	if err != nil {
+		return fmt.Errorf(errListItems, err)
+	}
+	// overviews carry no tags, so the full items are needed to filter on them
+	var tagged []onepassword.Item
+	err = forEach(items, func(overview *onepassword.ItemOverview) error {
+		item, err := provider.client.Items.Get(ctx, vault.ID, overview.ID)
+		if err != nil {
+			return fmt.Errorf(errGetItem, err)
+		}
+		if slices.Contains(item.Tags, tag) {
+			tagged = append(tagged, item)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, item := range tagged {
+		if err := provider.deletionProtected(&item); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if provider.dryRun {
+			loggerFrom(ctx).Info("dry run: would delete 1Password item", "vault", provider.redact.name(vault.Title), "item", provider.redact.name(item.Title))
+			*deleted++
+			continue
+		}
+		if err := provider.client.Items.Delete(ctx, vault.ID, item.ID); err != nil {
+			errs = append(errs, fmt.Errorf(errDeleteTaggedItem, item.Title, err))
+			continue
+		}
+		provider.itemIDs.delete(itemIDCacheKey(vault, item.Title))
+		provider.itemIDs.delete(itemIDCacheKey(vault, item.ID))
+		*deleted++
+	}
+	return errors.Join(errs...)
+}
+
+// checkDeletionProtection fails when the item is tagged with the deletion protection tag.
+func (provider *ProviderOnePasswordSdk) checkDeletionProtection(ctx context.Context, vaultID, itemID string) error {
+	if provider.protectedTag == "" {
+		return nil
+	}
+	item, err := provider.client.Items.Get(ctx, vaultID, itemID)
+	if err != nil {
+		return fmt.Errorf(errGetItem, err)
+	}
+	return provider.deletionProtected(&item)
+}
+
+// deletionProtected returns ErrDeletionProtected when item is tagged with the deletion
+// protection tag.
+func (provider *ProviderOnePasswordSdk) deletionProtected(item *onepassword.Item) error {
+	if provider.protectedTag != "" && slices.Contains(item.Tags, provider.protectedTag) {
+		return newTypedError(ErrDeletionProtected, fmt.Errorf(errDeletionProtected, item.Title, provider.protectedTag))
+	}
+	return nil
+}
+
+// resolveItemID is findItemID with the IDs found cached for itemIDTTL, so that pushing many
+// secrets into the same vault does not list its items, showing up in the audit log, every time.
+// Missing items are not cached, PushSecret caches the items it creates instead.
+func (provider *ProviderOnePasswordSdk) resolveItemID(ctx context.Context, vault *onepassword.VaultOverview, titleOrID string) (string, error) {
+	key := itemIDCacheKey(vault, titleOrID)
+	if itemID, ok := provider.itemIDs.get(key); ok {
+		return itemID, nil
+	}
+	itemID, err := provider.findItemID(ctx, vault, titleOrID)
+	if err != nil || itemID == "" {
+		return itemID, err
+	}
+	provider.itemIDs.add(key, itemID)
+	return itemID, nil
+}
+
+func itemIDCacheKey(vault *onepassword.VaultOverview, titleOrID string) string {
+	return vault.ID + cacheKeySep + titleOrID
+}
+
+// updateItem overwrites the pushed fields of an existing item, within the section of metadata if
+// any, and its tags unless they are nil. The item is only written when something actually
+// changed, so pushing identical data does not create a new item version.
+func (provider *ProviderOnePasswordSdk) updateItem(ctx context.Context, vaultID, itemID string, secret *corev1.Secret, fields []onepassword.ItemField, metadata *PushSecretMetadataSpec) error {
+	item, err := provider.client.Items.Get(ctx, vaultID, itemID)
+	if err != nil {
+		return fmt.Errorf(errGetItem, err)
+	}
+	if metadata.ExpectedVersion != nil && item.Version != *metadata.ExpectedVersion {
+		return newTypedError(ErrConflict, fmt.Errorf(errItemVersionConflict, item.Title, item.Version, *metadata.ExpectedVersion))
+	}
+	if metadata.Section != nil {
+		fields = inSection(fields, pushSectionID(&item, *metadata.Section))
+	}
+	fields = provider.markFields(secret, fields)
+	tags := provider.markTags(metadata.Tags, item.Tags)
+
+	var changed bool
+	item.Fields, changed, err = mergeFields(item.Title, item.Fields, fields, metadata.FieldTypes)
+	if err != nil {
+		return fmt.Errorf(errUpdateItem, err)
+	}
+	if tags != nil && !slices.Equal(item.Tags, tags) {
+		item.Tags = tags
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	if provider.dryRun {
+		loggerFrom(ctx).Info("dry run: would update 1Password item", "vaultID", vaultID, "item", provider.redact.name(item.Title), "fields", provider.redact.names(fieldLabels(fields)), "tags", provider.redact.names(item.Tags))
+		return nil
+	}
+
+	if _, err = provider.client.Items.Put(ctx, item); err != nil {
+		return fmt.Errorf(errUpdateItem, err)
+	}
+	return nil
+}
+
+// mergeFields sets the value of every pushed field on the existing field with the same label,
+// and section when the pushed field has one, appending fields that do not exist yet. The type of
+// an existing field is only set when fieldTypes names it. It reports whether anything changed.
+func mergeFields(itemTitle string, existing, pushed []onepassword.ItemField, fieldTypes map[string]onepassword.ItemFieldType) ([]onepassword.ItemField, bool, error) {
+	var changed bool
+	for _, field := range pushed {
+		index := -1
+		for i := range existing {
+			if existing[i].Title != field.Title {
+				continue
+			}
+			if field.SectionID != nil && (existing[i].SectionID == nil || *existing[i].SectionID != *field.SectionID) {
+				continue
+			}
+			if index != -1 {
+				return nil, false, fmt.Errorf(errExpectedOneField, field.Title, itemTitle)
+			}
+			index = i
+		}
+
+		if index == -1 {
+			existing = append(existing, field)
+			changed = true
+			continue
+		}
+		_, typed := fieldTypes[field.Title]
+		typeChanged := typed && existing[index].FieldType != field.FieldType
+		switch {
+		case existing[index].Value == field.Value && !typeChanged:
+		case existing[index].FieldType == onepassword.ItemFieldTypeTOTP:
+			return nil, false, fmt.Errorf(errFieldNotWritable, field.Title, existing[index].FieldType)
+		default:
+			existing[index].Value = field.Value
+			if typed {
+				existing[index].FieldType = field.FieldType
+			}
+			changed = true
+		}
+	}
+	return existing, changed, nil
+}
+
+// checkMarkerField fails when a key of the Secret is pushed to the field of the managed marker.
+func (provider *ProviderOnePasswordSdk) checkMarkerField(secret *corev1.Secret, fields []onepassword.ItemField) error {
+	if provider.marker == nil || provider.marker.SourceField == "" {
+		return nil
+	}
+	for _, field := range fields {
+		if field.Title == provider.marker.SourceField {
+			return fmt.Errorf(errMarkerField, secret.Name, field.Title)
+		}
+	}
+	return nil
+}
+
+// markFields appends the field of the managed marker, if any, holding the namespace and name of
+// the Secret, to the pushed fields. It is a text field, at the top level of the item.
+func (provider *ProviderOnePasswordSdk) markFields(secret *corev1.Secret, fields []onepassword.ItemField) []onepassword.ItemField {
+	if provider.marker == nil || provider.marker.SourceField == "" {
+		return fields
+	}
+	return append(slices.Clone(fields), onepassword.ItemField{
+		ID:        provider.marker.SourceField,
+		Title:     provider.marker.SourceField,
+		FieldType: onepassword.ItemFieldTypeText,
+		Value:     secret.Namespace + "/" + secret.Name,
+	})
+}
+
+// markTags returns the tags to set on a pushed item along with the tag of the managed marker, if
+// any. When tags is nil the current tags of the item are kept, so nil is returned unless they
+// lack the marker.
+func (provider *ProviderOnePasswordSdk) markTags(tags, current []string) []string {
+	if provider.marker == nil || provider.marker.Tag == "" {
+		return tags
+	}
+	if tags == nil {
+		if slices.Contains(current, provider.marker.Tag) {
+			return nil
+		}
+		tags = current
+	}
+	if slices.Contains(tags, provider.marker.Tag) {
+		return tags
+	}
+	return append(slices.Clone(tags), provider.marker.Tag)
+}
+
+// pushFields builds the item fields to write from the Secret, sorted by label. Fields are
+// concealed unless fieldTypes sets their type.
+func pushFields(secret *corev1.Secret, data esv1beta1.PushSecretData, fieldTypes map[string]onepassword.ItemFieldType) ([]onepassword.ItemField, error) {
+	key, label := data.GetSecretKey(), data.GetProperty()
+	if key == "" {
+		key = label
+	}
+	if key != "" {
+		value, ok := secret.Data[key]
+		if !ok {
+			return nil, fmt.Errorf(errSecretKeyNotFound, key, secret.Name)
+		}
+		if label == "" {
+			label = key
+		}
+		return withFieldTypes([]onepassword.ItemField{newConcealedField(label, value)}, fieldTypes), nil
+	}
+
+	if len(secret.Data) == 0 {
+		return nil, fmt.Errorf(errSecretHasNoData, secret.Name)
+	}
+	fields := make([]onepassword.ItemField, 0, len(secret.Data))
+	for key, value := range secret.Data {
+		fields = append(fields, newConcealedField(key, value))
+	}
+	slices.SortFunc(fields, func(a, b onepassword.ItemField) int {
+		return strings.Compare(a.Title, b.Title)
+	})
+	return withFieldTypes(fields, fieldTypes), nil
+}
+
+// withFieldTypes sets the type of the fields named by fieldTypes.
+func withFieldTypes(fields []onepassword.ItemField, fieldTypes map[string]onepassword.ItemFieldType) []onepassword.ItemField {
+	for i := range fields {
+		if fieldType, ok := fieldTypes[fields[i].Title]; ok {
+			fields[i].FieldType = fieldType
+		}
+	}
+	return fields
+}
+
+// newSection returns a section titled title, whose ID is title as well.
+func newSection(title string) onepassword.ItemSection {
+	return onepassword.ItemSection{ID: title, Title: title}
+}
+
+// pushSectionID returns the ID of the section of item whose title or ID equals title, adding
+// a new section to item when there is none.
+func pushSectionID(item *onepassword.Item, title string) string {
+	for _, section := range item.Sections {
+		if section.Title == title || section.ID == title {
+			return section.ID
+		}
+	}
+	section := newSection(title)
+	item.Sections = append(item.Sections, section)
+	return section.ID
+}
+
+// inSection returns a copy of fields placed in the section sectionID. Their IDs are prefixed
+// with the section so that they do not clash with fields of the same label in other sections.
+func inSection(fields []onepassword.ItemField, sectionID string) []onepassword.ItemField {
+	placed := make([]onepassword.ItemField, len(fields))
+	for i, field := range fields {
+		field.ID = sectionID + "." + field.ID
+		field.SectionID = &sectionID
+		placed[i] = field
+	}
+	return placed
+}
+
+// fieldLabels returns the labels of fields, for logging.
+func fieldLabels(fields []onepassword.ItemField) []string {
+	labels := make([]string, 0, len(fields))
+	for _, field := range fields {
+		labels = append(labels, fieldKey(field))
+	}
+	return labels
+}
+
+// newConcealedField returns a concealed field whose ID and label are both label.
+func newConcealedField(label string, value []byte) onepassword.ItemField {
+	return onepassword.ItemField{
+		ID:        label,
+		Title:     label,
+		FieldType: onepassword.ItemFieldTypeConcealed,
+		Value:     string(value),
+	}
+}
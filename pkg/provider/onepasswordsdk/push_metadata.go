@@ -0,0 +1,128 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/1password/onepassword-sdk-go"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	pushMetadataAPIVersion = "kubernetes.external-secrets.io/v1alpha1"
+	pushMetadataKind       = "PushSecretMetadata"
+
+	errParsePushMetadata = "failed to parse %s %s: %w"
+	errPushMetadataType  = "unexpected %s %q, expected %q"
+	errInvalidCategory   = "unsupported 1Password Item category %q in PushSecret metadata, expected one of: %v"
+	errEmptySection      = "empty 1Password Section name in PushSecret metadata"
+	errInvalidFieldType  = "unsupported 1Password ItemField type %q for %q in PushSecret metadata, expected one of: %v"
+)
+
+// pushCategories are the item categories PushSecret may create items as.
+var pushCategories = []onepassword.ItemCategory{
+	onepassword.ItemCategoryAPICredentials,
+	onepassword.ItemCategoryLogin,
+	onepassword.ItemCategoryPassword,
+	onepassword.ItemCategorySecureNote,
+}
+
+// pushFieldTypes are the field types PushSecret may write fields as.
+var pushFieldTypes = []onepassword.ItemFieldType{
+	onepassword.ItemFieldTypeConcealed,
+	onepassword.ItemFieldTypeText,
+	onepassword.ItemFieldTypeURL,
+	onepassword.ItemFieldTypePhone,
+}
+
+// PushSecretMetadata is the metadata of a PushSecret targeting 1Password, e.g.
+//
+//	apiVersion: kubernetes.external-secrets.io/v1alpha1
+//	kind: PushSecretMetadata
+//	spec:
+//	  category: Login
+//	  tags: [env/prod]
+//	  section: database
+//	  fieldTypes:
+//	    username: Text
+//	  expectedVersion: 3
+type PushSecretMetadata struct {
+	metav1.TypeMeta
+	Spec PushSecretMetadataSpec `json:"spec,omitempty"`
+}
+
+type PushSecretMetadataSpec struct {
+	// Category of the item when it is created, ApiCredentials by default.
+	// The category of an existing item cannot be changed.
+	Category onepassword.ItemCategory `json:"category,omitempty"`
+	// Tags of the item. The tags of an existing item are replaced when set.
+	Tags []string `json:"tags,omitempty"`
+	// Section, by title, the pushed fields are written into. It is created when missing, and
+	// existing fields are then only matched within it.
+	Section *string `json:"section,omitempty"`
+	// FieldTypes sets the type of pushed fields, keyed by label. Fields are Concealed by default.
+	// The type of an existing field is only changed when set here.
+	FieldTypes map[string]onepassword.ItemFieldType `json:"fieldTypes,omitempty"`
+	// ExpectedVersion is the version an existing item must be at for it to be updated, so that
+	// changes made to it since are not overwritten. The SDK does not expose when an item was
+	// updated, its version is raised by every change instead. The item is read right before it
+	// is written, a change made in between is still overwritten. Created items are not checked.
+	ExpectedVersion *uint32 `json:"expectedVersion,omitempty"`
+}
+
+// parsePushMetadata parses the metadata of a PushSecret, defaulting the category.
+func parsePushMetadata(data *apiextensionsv1.JSON) (*PushSecretMetadataSpec, error) {
+	spec := &PushSecretMetadataSpec{Category: defaultPushedCategory}
+	if data == nil {
+		return spec, nil
+	}
+
+	var metadata PushSecretMetadata
+	if err := yaml.Unmarshal(data.Raw, &metadata, yaml.DisallowUnknownFields); err != nil {
+		return nil, fmt.Errorf(errParsePushMetadata, pushMetadataAPIVersion, pushMetadataKind, err)
+	}
+	if metadata.APIVersion != pushMetadataAPIVersion {
+		return nil, fmt.Errorf(errPushMetadataType, "apiVersion", metadata.APIVersion, pushMetadataAPIVersion)
+	}
+	if metadata.Kind != pushMetadataKind {
+		return nil, fmt.Errorf(errPushMetadataType, "kind", metadata.Kind, pushMetadataKind)
+	}
+
+	if metadata.Spec.Category != "" {
+		if !slices.Contains(pushCategories, metadata.Spec.Category) {
+			return nil, fmt.Errorf(errInvalidCategory, metadata.Spec.Category, pushCategories)
+		}
+		spec.Category = metadata.Spec.Category
+	}
+	if metadata.Spec.Section != nil && strings.TrimSpace(*metadata.Spec.Section) == "" {
+		return nil, errors.New(errEmptySection)
+	}
+	for label, fieldType := range metadata.Spec.FieldTypes {
+		if !slices.Contains(pushFieldTypes, fieldType) {
+			return nil, fmt.Errorf(errInvalidFieldType, fieldType, label, pushFieldTypes)
+		}
+	}
+	spec.Tags = metadata.Spec.Tags
+	spec.Section = metadata.Spec.Section
+	spec.FieldTypes = metadata.Spec.FieldTypes
+	spec.ExpectedVersion = metadata.Spec.ExpectedVersion
+	return spec, nil
+}
@@ -0,0 +1,980 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/1password/onepassword-sdk-go"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	esv1alpha1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1alpha1"
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	"github.com/external-secrets/external-secrets/pkg/provider/onepasswordsdk/fake"
+	testingfake "github.com/external-secrets/external-secrets/pkg/provider/testing/fake"
+)
+
+const newItem = "new-item"
+
+func pushMetadata(spec string) *apiextensionsv1.JSON {
+	return &apiextensionsv1.JSON{Raw: []byte(`{"apiVersion":"kubernetes.external-secrets.io/v1alpha1","kind":"PushSecretMetadata","spec":` + spec + `}`)}
+}
+
+func newPushSecret() *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-secret"},
+		Data: map[string][]byte{
+			key1: []byte(value1),
+			key2: []byte(value2),
+		},
+	}
+}
+
+func TestPushSecretCreate(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       testingfake.PushSecretData
+		wantFields []onepassword.ItemField
+		wantErr    string
+	}{
+		{
+			name: "every key becomes a field",
+			data: testingfake.PushSecretData{RemoteKey: "op://my-vault/new-item"},
+			wantFields: []onepassword.ItemField{
+				newConcealedField(key1, []byte(value1)),
+				newConcealedField(key2, []byte(value2)),
+			},
+		},
+		{
+			name: "single key defaults label to the key",
+			data: testingfake.PushSecretData{RemoteKey: "op://my-vault/new-item", SecretKey: key2},
+			wantFields: []onepassword.ItemField{
+				newConcealedField(key2, []byte(value2)),
+			},
+		},
+		{
+			name: "single key with property",
+			data: testingfake.PushSecretData{RemoteKey: "op://my-vault/new-item", SecretKey: key1, Property: "password"},
+			wantFields: []onepassword.ItemField{
+				newConcealedField("password", []byte(value1)),
+			},
+		},
+		{
+			name:    "missing secret key",
+			data:    testingfake.PushSecretData{RemoteKey: "op://my-vault/new-item", SecretKey: "missing"},
+			wantErr: `key "missing" not found in Secret "my-secret"`,
+		},
+		{
+			name:    "vault not found",
+			data:    testingfake.PushSecretData{RemoteKey: "op://missing/new-item"},
+			wantErr: `1Password Vault "missing" not found`,
+		},
+		{
+			name:    "field reference",
+			data:    testingfake.PushSecretData{RemoteKey: "op://my-vault/new-item/field"},
+			wantErr: "expected an item-level reference",
+		},
+		{
+			name:    "blank title",
+			data:    testingfake.PushSecretData{RemoteKey: "op://my-vault/%20"},
+			wantErr: `blank 1Password Item title in "op://my-vault/%20"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewClient().AddVault(myVaultID, myVault)
+			provider := &ProviderOnePasswordSdk{client: client.SDKClient()}
+			err := provider.PushSecret(context.Background(), newPushSecret(), tt.data)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			if assert.Len(t, client.MockItems[myVaultID], 1) {
+				item := client.MockItems[myVaultID][0]
+				assert.Equal(t, newItem, item.Title)
+				assert.Equal(t, onepassword.ItemCategoryAPICredentials, item.Category)
+				assert.Equal(t, tt.wantFields, item.Fields)
+			}
+		})
+	}
+}
+
+func TestPushSecretUpdate(t *testing.T) {
+	existing := func(fields ...onepassword.ItemField) *fake.Client {
+		return fake.NewClient().
+			AddVault(myVaultID, myVault).
+			AddItem(onepassword.Item{
+				ID:      myItemID,
+				Title:   myItem,
+				VaultID: myVaultID,
+				Fields:  fields,
+				Version: 1,
+			})
+	}
+	tests := []struct {
+		name        string
+		client      *fake.Client
+		data        testingfake.PushSecretData
+		wantFields  []onepassword.ItemField
+		wantVersion uint32
+		wantErr     string
+	}{
+		{
+			name:   "overwrites matching labels and keeps others",
+			client: existing(newConcealedField(key1, []byte("old")), newConcealedField("other", []byte("keep"))),
+			data:   testingfake.PushSecretData{RemoteKey: "op://my-vault/my-item"},
+			wantFields: []onepassword.ItemField{
+				newConcealedField(key1, []byte(value1)),
+				newConcealedField("other", []byte("keep")),
+				newConcealedField(key2, []byte(value2)),
+			},
+			wantVersion: 2,
+		},
+		{
+			name:   "property updates only its field",
+			client: existing(newConcealedField("password", []byte("old")), newConcealedField("other", []byte("keep"))),
+			data:   testingfake.PushSecretData{RemoteKey: "op://my-vault/my-item", SecretKey: key1, Property: "password"},
+			wantFields: []onepassword.ItemField{
+				newConcealedField("password", []byte(value1)),
+				newConcealedField("other", []byte("keep")),
+			},
+			wantVersion: 2,
+		},
+		{
+			name:   "property creates its missing field",
+			client: existing(newConcealedField("other", []byte("keep"))),
+			data:   testingfake.PushSecretData{RemoteKey: "op://my-vault/my-item", SecretKey: key1, Property: "password"},
+			wantFields: []onepassword.ItemField{
+				newConcealedField("other", []byte("keep")),
+				newConcealedField("password", []byte(value1)),
+			},
+			wantVersion: 2,
+		},
+		{
+			name:   "property without secret key pushes the key of the same name",
+			client: existing(newConcealedField(key1, []byte("old")), newConcealedField(key2, []byte("keep"))),
+			data:   testingfake.PushSecretData{RemoteKey: "op://my-vault/my-item", Property: key1},
+			wantFields: []onepassword.ItemField{
+				newConcealedField(key1, []byte(value1)),
+				newConcealedField(key2, []byte("keep")),
+			},
+			wantVersion: 2,
+		},
+		{
+			name:    "property without secret key or key of the same name",
+			client:  existing(),
+			data:    testingfake.PushSecretData{RemoteKey: "op://my-vault/my-item", Property: "password"},
+			wantErr: `key "password" not found in Secret`,
+		},
+		{
+			name:   "identical data does not create a new version",
+			client: existing(newConcealedField(key1, []byte(value1)), newConcealedField(key2, []byte(value2))),
+			data:   testingfake.PushSecretData{RemoteKey: "op://my-vault/my-item"},
+			wantFields: []onepassword.ItemField{
+				newConcealedField(key1, []byte(value1)),
+				newConcealedField(key2, []byte(value2)),
+			},
+			wantVersion: 1,
+		},
+		{
+			name: "totp field cannot be overwritten",
+			client: existing(onepassword.ItemField{
+				ID:        key1,
+				Title:     key1,
+				FieldType: onepassword.ItemFieldTypeTOTP,
+				Value:     "otpauth://totp/seed",
+			}),
+			data:    testingfake.PushSecretData{RemoteKey: "op://my-vault/my-item", SecretKey: key1},
+			wantErr: `1Password ItemField "key1" of type Totp cannot be overwritten`,
+		},
+		{
+			name:    "duplicate labels",
+			client:  existing(newConcealedField(key1, []byte("a")), newConcealedField(key1, []byte("b"))),
+			data:    testingfake.PushSecretData{RemoteKey: "op://my-vault/my-item", SecretKey: key1},
+			wantErr: "expected one 1Password ItemField labeled",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &ProviderOnePasswordSdk{client: tt.client.SDKClient()}
+			err := provider.PushSecret(context.Background(), newPushSecret(), tt.data)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			if assert.Len(t, tt.client.MockItems[myVaultID], 1) {
+				item := tt.client.MockItems[myVaultID][0]
+				assert.Equal(t, tt.wantFields, item.Fields)
+				assert.Equal(t, tt.wantVersion, item.Version)
+			}
+		})
+	}
+}
+
+func TestSecretExists(t *testing.T) {
+	errForbidden := errors.New("forbidden")
+	tests := []struct {
+		name    string
+		client  *fake.Client
+		ref     esv1alpha1.PushSecretRemoteRef
+		want    bool
+		wantErr string
+	}{
+		{
+			name:   "item exists",
+			client: newFakeClient(),
+			ref:    esv1alpha1.PushSecretRemoteRef{RemoteKey: "op://my-vault/my-item"},
+			want:   true,
+		},
+		{
+			name:   "item missing",
+			client: newFakeClient(),
+			ref:    esv1alpha1.PushSecretRemoteRef{RemoteKey: "op://my-vault/missing"},
+			want:   false,
+		},
+		{
+			name:   "field exists by label",
+			client: newFakeClient(),
+			ref:    esv1alpha1.PushSecretRemoteRef{RemoteKey: "op://my-vault/my-item", Property: key1},
+			want:   true,
+		},
+		{
+			name:   "field exists by ID",
+			client: newFakeClient(),
+			ref:    esv1alpha1.PushSecretRemoteRef{RemoteKey: "op://my-vault/my-item", Property: "website"},
+			want:   true,
+		},
+		{
+			name:   "field exists by reference",
+			client: newFakeClient(),
+			ref:    esv1alpha1.PushSecretRemoteRef{RemoteKey: "op://my-vault/my-item/key2"},
+			want:   true,
+		},
+		{
+			name:   "field missing",
+			client: newFakeClient(),
+			ref:    esv1alpha1.PushSecretRemoteRef{RemoteKey: "op://my-vault/my-item", Property: "missing"},
+			want:   false,
+		},
+		{
+			name:    "vault not accessible",
+			client:  newFakeClient(),
+			ref:     esv1alpha1.PushSecretRemoteRef{RemoteKey: "op://missing/my-item"},
+			wantErr: "not found or not accessible to the service account",
+		},
+		{
+			name:    "permission denied listing items",
+			client:  newFakeClient().WithError(fake.ItemsListAll, errForbidden),
+			ref:     esv1alpha1.PushSecretRemoteRef{RemoteKey: "op://my-vault/my-item"},
+			wantErr: "error listing 1Password Items: forbidden",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &ProviderOnePasswordSdk{client: tt.client.SDKClient()}
+			got, err := provider.SecretExists(context.Background(), tt.ref)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDeleteSecret(t *testing.T) {
+	tests := []struct {
+		name       string
+		ref        esv1alpha1.PushSecretRemoteRef
+		wantItems  int
+		wantFields []string
+		wantErr    string
+	}{
+		{
+			name:      "deletes the whole item",
+			ref:       esv1alpha1.PushSecretRemoteRef{RemoteKey: "op://my-vault/my-item"},
+			wantItems: 0,
+		},
+		{
+			name:       "deletes a single field",
+			ref:        esv1alpha1.PushSecretRemoteRef{RemoteKey: "op://my-vault/my-item", Property: key1},
+			wantItems:  1,
+			wantFields: []string{key2, ""},
+		},
+		{
+			name:       "deletes a single field by reference",
+			ref:        esv1alpha1.PushSecretRemoteRef{RemoteKey: "op://my-vault/my-item/key1"},
+			wantItems:  1,
+			wantFields: []string{key2, ""},
+		},
+		{
+			name:       "missing field is a no-op",
+			ref:        esv1alpha1.PushSecretRemoteRef{RemoteKey: "op://my-vault/my-item", Property: "missing"},
+			wantItems:  1,
+			wantFields: []string{key1, key2, ""},
+		},
+		{
+			name:       "missing item is a no-op",
+			ref:        esv1alpha1.PushSecretRemoteRef{RemoteKey: "op://my-vault/missing"},
+			wantItems:  1,
+			wantFields: []string{key1, key2, ""},
+		},
+		{
+			name:    "missing vault",
+			ref:     esv1alpha1.PushSecretRemoteRef{RemoteKey: "op://missing/my-item"},
+			wantErr: "not found or not accessible",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newFakeClient()
+			provider := &ProviderOnePasswordSdk{client: client.SDKClient()}
+			err := provider.DeleteSecret(context.Background(), tt.ref)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			items := client.MockItems[myVaultID]
+			if assert.Len(t, items, tt.wantItems) && tt.wantItems > 0 {
+				var labels []string
+				for _, field := range items[0].Fields {
+					labels = append(labels, field.Title)
+				}
+				assert.Equal(t, tt.wantFields, labels)
+			}
+		})
+	}
+}
+
+func TestDeleteSecretSections(t *testing.T) {
+	newClient := func() *fake.Client {
+		return fake.NewClient().
+			AddVault(myVaultID, myVault).
+			AddItem(onepassword.Item{
+				ID:       myItemID,
+				Title:    myItem,
+				Category: onepassword.ItemCategoryDatabase,
+				VaultID:  myVaultID,
+				Sections: []onepassword.ItemSection{
+					{ID: "s1", Title: "production"},
+					{ID: "s2", Title: "staging"},
+				},
+				Fields: []onepassword.ItemField{
+					{ID: "f1", Title: "password", SectionID: ptr.To("s1"), FieldType: onepassword.ItemFieldTypeConcealed, Value: value1},
+					{ID: "f2", Title: "password", SectionID: ptr.To("s2"), FieldType: onepassword.ItemFieldTypeConcealed, Value: value2},
+				},
+			})
+	}
+	tests := []struct {
+		name       string
+		ref        esv1alpha1.PushSecretRemoteRef
+		wantExists bool
+		wantFields []string
+		wantErr    string
+	}{
+		{
+			name:       "deletes the field of the section",
+			ref:        esv1alpha1.PushSecretRemoteRef{RemoteKey: "op://my-vault/my-item/staging/password"},
+			wantExists: true,
+			wantFields: []string{"f1"},
+		},
+		{
+			name:       "section by ID",
+			ref:        esv1alpha1.PushSecretRemoteRef{RemoteKey: "op://my-vault/my-item/s1/password"},
+			wantExists: true,
+			wantFields: []string{"f2"},
+		},
+		{
+			name:       "field by ID",
+			ref:        esv1alpha1.PushSecretRemoteRef{RemoteKey: "op://my-vault/my-item", Property: "f2"},
+			wantExists: true,
+			wantFields: []string{"f1"},
+		},
+		{
+			name:       "missing section is a no-op",
+			ref:        esv1alpha1.PushSecretRemoteRef{RemoteKey: "op://my-vault/my-item/missing/password"},
+			wantFields: []string{"f1", "f2"},
+		},
+		{
+			name:       "ambiguous label",
+			ref:        esv1alpha1.PushSecretRemoteRef{RemoteKey: "op://my-vault/my-item", Property: "password"},
+			wantExists: true,
+			wantFields: []string{"f1", "f2"},
+			wantErr:    `in more than one section of Item "my-item", qualify it as op://<vault>/<item>/<section>/<field> with one of: production, staging`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newClient()
+			provider := &ProviderOnePasswordSdk{client: client.SDKClient()}
+			exists, err := provider.SecretExists(context.Background(), tt.ref)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantExists, exists)
+
+			err = provider.DeleteSecret(context.Background(), tt.ref)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			var ids []string
+			for _, field := range client.MockItems[myVaultID][0].Fields {
+				ids = append(ids, field.ID)
+			}
+			assert.Equal(t, tt.wantFields, ids)
+		})
+	}
+}
+
+func TestDeleteSecretDeletionProtection(t *testing.T) {
+	const protectedTag = "protected"
+	ctx := context.Background()
+	client := newFakeClient().
+		AddItem(onepassword.Item{ID: "critical-id", Title: "critical", VaultID: myVaultID, Tags: []string{protectedTag}, Fields: []onepassword.ItemField{
+			newConcealedField(key1, []byte(value1)),
+		}}).
+		AddItem(onepassword.Item{ID: "cleanup-id", Title: "cleanup", VaultID: myVaultID, Tags: []string{"cleanup", protectedTag}})
+	provider := &ProviderOnePasswordSdk{client: client.SDKClient(), itemIDs: newTTLCache[string](itemIDTTL), protectedTag: protectedTag}
+
+	err := provider.DeleteSecret(ctx, esv1alpha1.PushSecretRemoteRef{RemoteKey: "op://my-vault/critical"})
+	assert.ErrorIs(t, err, ErrDeletionProtected)
+	assert.EqualError(t, err, `1Password Item "critical" is tagged "protected", which spec.provider.onepasswordsdk.deletionProtectionTag protects from deletion`)
+	assert.Len(t, client.MockItems[myVaultID], 3)
+
+	// the tag is redacted along with the item
+	provider.redact = true
+	err = provider.DeleteSecret(ctx, esv1alpha1.PushSecretRemoteRef{RemoteKey: "op://my-vault/critical"})
+	assert.ErrorIs(t, err, ErrDeletionProtected)
+	assert.NotContains(t, err.Error(), "critical")
+	assert.NotContains(t, err.Error(), protectedTag)
+	provider.redact = false
+
+	// its fields can still be deleted
+	assert.NoError(t, provider.DeleteSecret(ctx, esv1alpha1.PushSecretRemoteRef{RemoteKey: "op://my-vault/critical", Property: key1}))
+	assert.Empty(t, client.MockItems[myVaultID][1].Fields)
+
+	// nor is it deleted by tag
+	deleted, err := provider.DeleteSecretsByTag(ctx, myVault, "cleanup")
+	assert.ErrorIs(t, err, ErrDeletionProtected)
+	assert.Zero(t, deleted)
+	assert.Len(t, client.MockItems[myVaultID], 3)
+
+	// items without the tag are deleted as before
+	assert.NoError(t, provider.DeleteSecret(ctx, esv1alpha1.PushSecretRemoteRef{RemoteKey: "op://my-vault/my-item"}))
+	assert.Len(t, client.MockItems[myVaultID], 2)
+}
+
+func TestDeleteSecretsByTag(t *testing.T) {
+	newClient := func() *fake.Client {
+		return newFakeClient().
+			AddItem(onepassword.Item{ID: "tagged1", Title: "tagged-1", VaultID: myVaultID, Tags: []string{"cleanup"}}).
+			AddItem(onepassword.Item{ID: "tagged2", Title: "tagged-2", VaultID: myVaultID, Tags: []string{"env/prod", "cleanup"}})
+	}
+	tests := []struct {
+		name        string
+		client      *fake.Client
+		vault       string
+		tag         string
+		dryRun      bool
+		wantDeleted int
+		wantItems   []string
+		wantErr     string
+	}{
+		{
+			name:        "deletes the tagged items",
+			client:      newClient(),
+			vault:       myVault,
+			tag:         "cleanup",
+			wantDeleted: 2,
+			wantItems:   []string{myItem},
+		},
+		{
+			name:        "nested tags match as a whole",
+			client:      newClient(),
+			vault:       myVault,
+			tag:         "env",
+			wantDeleted: 0,
+			wantItems:   []string{myItem, "tagged-1", "tagged-2"},
+		},
+		{
+			name:        "failing item does not stop the others",
+			client:      newClient().WithErrorTimes(fake.ItemsDelete, errors.New("forbidden"), 1),
+			vault:       myVault,
+			tag:         "cleanup",
+			wantDeleted: 1,
+			wantItems:   []string{myItem, "tagged-1"},
+			wantErr:     `error deleting 1Password Item "tagged-1": forbidden`,
+		},
+		{
+			name:        "dry run",
+			client:      newClient(),
+			vault:       myVault,
+			tag:         "cleanup",
+			dryRun:      true,
+			wantDeleted: 2,
+			wantItems:   []string{myItem, "tagged-1", "tagged-2"},
+		},
+		{
+			name:    "vault is required",
+			client:  newClient(),
+			tag:     "cleanup",
+			wantErr: "a vault is required",
+		},
+		{
+			name:    "tag is required",
+			client:  newClient(),
+			vault:   myVault,
+			wantErr: "a tag is required",
+		},
+		{
+			name:    "missing vault",
+			client:  newClient(),
+			vault:   "missing",
+			tag:     "cleanup",
+			wantErr: "not found or not accessible",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &ProviderOnePasswordSdk{client: tt.client.SDKClient(), dryRun: tt.dryRun}
+			deleted, err := provider.DeleteSecretsByTag(context.Background(), tt.vault, tt.tag)
+			assert.Equal(t, tt.wantDeleted, deleted)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			if tt.wantItems != nil {
+				var titles []string
+				for _, item := range tt.client.MockItems[myVaultID] {
+					titles = append(titles, item.Title)
+				}
+				assert.Equal(t, tt.wantItems, titles)
+			}
+		})
+	}
+}
+
+func TestResolveItemIDCache(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient()
+	provider := &ProviderOnePasswordSdk{client: client.SDKClient(), itemIDs: newTTLCache[string](itemIDTTL)}
+	ref := esv1alpha1.PushSecretRemoteRef{RemoteKey: "op://my-vault/new-item"}
+
+	assert.NoError(t, provider.PushSecret(ctx, newPushSecret(), testingfake.PushSecretData{RemoteKey: ref.RemoteKey, SecretKey: key1}))
+	assert.Equal(t, 1, client.Calls[fake.ItemsListAll])
+	assert.Equal(t, 1, client.Calls[fake.ItemsCreate])
+
+	// the item created is found without listing the vault again
+	assert.NoError(t, provider.PushSecret(ctx, newPushSecret(), testingfake.PushSecretData{RemoteKey: ref.RemoteKey, SecretKey: key2}))
+	exists, err := provider.SecretExists(ctx, ref)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, 1, client.Calls[fake.ItemsListAll])
+	assert.Equal(t, 1, client.Calls[fake.ItemsCreate])
+
+	// deleting the item drops its ID
+	assert.NoError(t, provider.DeleteSecret(ctx, ref))
+	exists, err = provider.SecretExists(ctx, ref)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	assert.Equal(t, 2, client.Calls[fake.ItemsListAll])
+}
+
+func TestPushSecretMetadata(t *testing.T) {
+	tests := []struct {
+		name         string
+		client       *fake.Client
+		metadata     *apiextensionsv1.JSON
+		wantCategory onepassword.ItemCategory
+		wantTags     []string
+		wantVersion  uint32
+		wantErr      string
+	}{
+		{
+			name:         "create with category and tags",
+			client:       fake.NewClient().AddVault(myVaultID, myVault),
+			metadata:     pushMetadata(`{"category":"Login","tags":["env/prod","team"]}`),
+			wantCategory: onepassword.ItemCategoryLogin,
+			wantTags:     []string{"env/prod", "team"},
+			wantVersion:  1,
+		},
+		{
+			name:         "create with tags only",
+			client:       fake.NewClient().AddVault(myVaultID, myVault),
+			metadata:     pushMetadata(`{"tags":["team"]}`),
+			wantCategory: onepassword.ItemCategoryAPICredentials,
+			wantTags:     []string{"team"},
+			wantVersion:  1,
+		},
+		{
+			name: "update replaces tags",
+			client: fake.NewClient().AddVault(myVaultID, myVault).AddItem(onepassword.Item{
+				ID: "new-item-id", Title: newItem, VaultID: myVaultID, Category: onepassword.ItemCategorySecureNote,
+				Fields:  []onepassword.ItemField{newConcealedField(key1, []byte(value1)), newConcealedField(key2, []byte(value2))},
+				Tags:    []string{"old"},
+				Version: 1,
+			}),
+			metadata:     pushMetadata(`{"category":"Login","tags":["new"]}`),
+			wantCategory: onepassword.ItemCategorySecureNote,
+			wantTags:     []string{"new"},
+			wantVersion:  2,
+		},
+		{
+			name: "update without tags keeps them",
+			client: fake.NewClient().AddVault(myVaultID, myVault).AddItem(onepassword.Item{
+				ID: "new-item-id", Title: newItem, VaultID: myVaultID, Category: onepassword.ItemCategorySecureNote,
+				Fields:  []onepassword.ItemField{newConcealedField(key1, []byte(value1)), newConcealedField(key2, []byte(value2))},
+				Tags:    []string{"old"},
+				Version: 1,
+			}),
+			wantCategory: onepassword.ItemCategorySecureNote,
+			wantTags:     []string{"old"},
+			wantVersion:  1,
+		},
+		{
+			name:     "unsupported category",
+			client:   fake.NewClient().AddVault(myVaultID, myVault),
+			metadata: pushMetadata(`{"category":"CreditCard"}`),
+			wantErr:  `unsupported 1Password Item category "CreditCard"`,
+		},
+		{
+			name:     "unknown field",
+			client:   fake.NewClient().AddVault(myVaultID, myVault),
+			metadata: pushMetadata(`{"labels":{"a":"b"}}`),
+			wantErr:  "failed to parse kubernetes.external-secrets.io/v1alpha1 PushSecretMetadata",
+		},
+		{
+			name:     "wrong kind",
+			client:   fake.NewClient().AddVault(myVaultID, myVault),
+			metadata: &apiextensionsv1.JSON{Raw: []byte(`{"apiVersion":"kubernetes.external-secrets.io/v1alpha1","kind":"Other"}`)},
+			wantErr:  `unexpected kind "Other"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &ProviderOnePasswordSdk{client: tt.client.SDKClient()}
+			err := provider.PushSecret(context.Background(), newPushSecret(), testingfake.PushSecretData{
+				RemoteKey: "op://my-vault/new-item",
+				Metadata:  tt.metadata,
+			})
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			if assert.Len(t, tt.client.MockItems[myVaultID], 1) {
+				item := tt.client.MockItems[myVaultID][0]
+				assert.Equal(t, tt.wantCategory, item.Category)
+				assert.Equal(t, tt.wantTags, item.Tags)
+				assert.Equal(t, tt.wantVersion, item.Version)
+			}
+		})
+	}
+}
+
+func TestPushSecretConflict(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient()
+	provider := &ProviderOnePasswordSdk{client: client.SDKClient()}
+	push := func(value string, metadata *apiextensionsv1.JSON) error {
+		secret := &corev1.Secret{Data: map[string][]byte{key1: []byte(value)}}
+		return provider.PushSecret(ctx, secret, testingfake.PushSecretData{SecretKey: key1, RemoteKey: "op://my-vault/my-item", Metadata: metadata})
+	}
+
+	// the item is at version 3
+	assert.NoError(t, push("first", pushMetadata(`{"expectedVersion":3}`)))
+	assert.Equal(t, uint32(4), client.MockItems[myVaultID][0].Version)
+
+	// someone else modifies the item in the meantime
+	item, err := client.SDKClient().Items.Get(ctx, myVaultID, myItemID)
+	assert.NoError(t, err)
+	item.Fields[0].Value = "concurrent"
+	_, err = client.SDKClient().Items.Put(ctx, item)
+	assert.NoError(t, err)
+
+	err = push("second", pushMetadata(`{"expectedVersion":4}`))
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.EqualError(t, err, `1Password Item "my-item" is at version 5, expected version 4: it was modified since`)
+	assert.Equal(t, "concurrent", client.MockItems[myVaultID][0].Fields[0].Value)
+
+	// without an expected version the item is overwritten as before
+	assert.NoError(t, push("third", nil))
+	assert.Equal(t, "third", client.MockItems[myVaultID][0].Fields[0].Value)
+}
+
+func TestPushSecretManagedMarker(t *testing.T) {
+	const (
+		managedTag  = "managed-by:external-secrets"
+		sourceField = "external-secrets-source"
+	)
+	ctx := context.Background()
+	client := newFakeClient()
+	provider := &ProviderOnePasswordSdk{
+		client: client.SDKClient(),
+		marker: &esv1beta1.OnePasswordSdkManagedMarker{Tag: managedTag, SourceField: sourceField},
+	}
+	secret := newPushSecret()
+	secret.Namespace = "team-a"
+	source := onepassword.ItemField{ID: sourceField, Title: sourceField, FieldType: onepassword.ItemFieldTypeText, Value: "team-a/my-secret"}
+
+	// a created item is stamped
+	assert.NoError(t, provider.PushSecret(ctx, secret, testingfake.PushSecretData{RemoteKey: "op://my-vault/new-item", SecretKey: key1}))
+	created := client.MockItems[myVaultID][1]
+	assert.Equal(t, []string{managedTag}, created.Tags)
+	assert.Equal(t, []onepassword.ItemField{newConcealedField(key1, []byte(value1)), source}, created.Fields)
+
+	// an updated item is stamped too, its tags kept
+	client.MockItems[myVaultID][0].Tags = []string{tagTeam}
+	assert.NoError(t, provider.PushSecret(ctx, secret, testingfake.PushSecretData{RemoteKey: "op://my-vault/my-item", SecretKey: key2, Property: key1}))
+	updated := client.MockItems[myVaultID][0]
+	assert.Equal(t, uint32(4), updated.Version)
+	assert.Equal(t, []string{tagTeam, managedTag}, updated.Tags)
+	assert.Contains(t, updated.Fields, source)
+
+	// the marker is kept when the tags are set, and pushing the same data again writes nothing
+	data := testingfake.PushSecretData{RemoteKey: "op://my-vault/my-item", SecretKey: key2, Property: key1, Metadata: pushMetadata(`{"tags":["env/prod"]}`)}
+	assert.NoError(t, provider.PushSecret(ctx, secret, data))
+	assert.Equal(t, []string{tagProd, managedTag}, client.MockItems[myVaultID][0].Tags)
+	assert.NoError(t, provider.PushSecret(ctx, secret, data))
+	assert.Equal(t, uint32(5), client.MockItems[myVaultID][0].Version)
+
+	// a key of the Secret cannot be pushed to the marker field
+	err := provider.PushSecret(ctx, secret, testingfake.PushSecretData{RemoteKey: "op://my-vault/my-item", SecretKey: key1, Property: sourceField})
+	assert.EqualError(t, err, `Secret "my-secret" is pushed to the 1Password ItemField "external-secrets-source", which spec.provider.onepasswordsdk.managedMarker.sourceField sets`)
+
+	// items are left alone without a marker
+	provider.marker = nil
+	assert.NoError(t, provider.PushSecret(ctx, secret, testingfake.PushSecretData{RemoteKey: "op://my-vault/other-item", SecretKey: key1}))
+	assert.Nil(t, client.MockItems[myVaultID][2].Tags)
+	assert.Len(t, client.MockItems[myVaultID][2].Fields, 1)
+}
+
+func TestPushSecretFieldTypes(t *testing.T) {
+	typed := func(fieldType onepassword.ItemFieldType, field onepassword.ItemField) onepassword.ItemField {
+		field.FieldType = fieldType
+		return field
+	}
+	existing := func(fields ...onepassword.ItemField) *fake.Client {
+		return fake.NewClient().AddVault(myVaultID, myVault).AddItem(onepassword.Item{
+			ID: "new-item-id", Title: newItem, VaultID: myVaultID, Fields: fields, Version: 1,
+		})
+	}
+	tests := []struct {
+		name        string
+		client      *fake.Client
+		metadata    *apiextensionsv1.JSON
+		want        []onepassword.ItemField
+		wantVersion uint32
+		wantErr     string
+	}{
+		{
+			name:     "create with mixed types",
+			client:   fake.NewClient().AddVault(myVaultID, myVault),
+			metadata: pushMetadata(`{"fieldTypes":{"key1":"Text"}}`),
+			want: []onepassword.ItemField{
+				typed(onepassword.ItemFieldTypeText, newConcealedField(key1, []byte(value1))),
+				newConcealedField(key2, []byte(value2)),
+			},
+			wantVersion: 1,
+		},
+		{
+			name: "update changes the type of a field",
+			client: existing(
+				newConcealedField(key1, []byte(value1)),
+				newConcealedField(key2, []byte(value2)),
+			),
+			metadata: pushMetadata(`{"fieldTypes":{"key1":"Text","key2":"Concealed"}}`),
+			want: []onepassword.ItemField{
+				typed(onepassword.ItemFieldTypeText, newConcealedField(key1, []byte(value1))),
+				newConcealedField(key2, []byte(value2)),
+			},
+			wantVersion: 2,
+		},
+		{
+			name: "update keeps the type of fields without one",
+			client: existing(
+				typed(onepassword.ItemFieldTypeURL, newConcealedField(key1, []byte("old"))),
+				newConcealedField(key2, []byte(value2)),
+			),
+			want: []onepassword.ItemField{
+				typed(onepassword.ItemFieldTypeURL, newConcealedField(key1, []byte(value1))),
+				newConcealedField(key2, []byte(value2)),
+			},
+			wantVersion: 2,
+		},
+		{
+			name:     "unsupported type",
+			client:   fake.NewClient().AddVault(myVaultID, myVault),
+			metadata: pushMetadata(`{"fieldTypes":{"key1":"Totp"}}`),
+			wantErr:  `unsupported 1Password ItemField type "Totp" for "key1"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &ProviderOnePasswordSdk{client: tt.client.SDKClient()}
+			err := provider.PushSecret(context.Background(), newPushSecret(), testingfake.PushSecretData{
+				RemoteKey: "op://my-vault/new-item",
+				Metadata:  tt.metadata,
+			})
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			if assert.Len(t, tt.client.MockItems[myVaultID], 1) {
+				item := tt.client.MockItems[myVaultID][0]
+				assert.Equal(t, tt.want, item.Fields)
+				assert.Equal(t, tt.wantVersion, item.Version)
+			}
+		})
+	}
+}
+
+func TestPushSecretSection(t *testing.T) {
+	sectioned := func(sectionID string, field onepassword.ItemField) onepassword.ItemField {
+		field.SectionID = &sectionID
+		return field
+	}
+	tests := []struct {
+		name         string
+		client       *fake.Client
+		metadata     *apiextensionsv1.JSON
+		wantSections []onepassword.ItemSection
+		wantFields   []onepassword.ItemField
+		wantErr      string
+	}{
+		{
+			name:         "create in section",
+			client:       fake.NewClient().AddVault(myVaultID, myVault),
+			metadata:     pushMetadata(`{"section":"database"}`),
+			wantSections: []onepassword.ItemSection{{ID: "database", Title: "database"}},
+			wantFields: []onepassword.ItemField{
+				sectioned("database", onepassword.ItemField{ID: "database.key1", Title: key1, FieldType: onepassword.ItemFieldTypeConcealed, Value: value1}),
+				sectioned("database", onepassword.ItemField{ID: "database.key2", Title: key2, FieldType: onepassword.ItemFieldTypeConcealed, Value: value2}),
+			},
+		},
+		{
+			name: "update matches fields by section and label",
+			client: fake.NewClient().AddVault(myVaultID, myVault).AddItem(onepassword.Item{
+				ID: "new-item-id", Title: newItem, VaultID: myVaultID,
+				Sections: []onepassword.ItemSection{{ID: "s1", Title: "database"}},
+				Fields: []onepassword.ItemField{
+					newConcealedField(key1, []byte("outside")),
+					sectioned("s1", onepassword.ItemField{ID: "f1", Title: key1, FieldType: onepassword.ItemFieldTypeConcealed, Value: "old"}),
+				},
+			}),
+			metadata:     pushMetadata(`{"section":"database"}`),
+			wantSections: []onepassword.ItemSection{{ID: "s1", Title: "database"}},
+			wantFields: []onepassword.ItemField{
+				newConcealedField(key1, []byte("outside")),
+				sectioned("s1", onepassword.ItemField{ID: "f1", Title: key1, FieldType: onepassword.ItemFieldTypeConcealed, Value: value1}),
+				sectioned("s1", onepassword.ItemField{ID: "s1.key2", Title: key2, FieldType: onepassword.ItemFieldTypeConcealed, Value: value2}),
+			},
+		},
+		{
+			name: "update creates a missing section",
+			client: fake.NewClient().AddVault(myVaultID, myVault).AddItem(onepassword.Item{
+				ID: "new-item-id", Title: newItem, VaultID: myVaultID,
+				Fields: []onepassword.ItemField{newConcealedField(key1, []byte(value1))},
+			}),
+			metadata:     pushMetadata(`{"section":"database"}`),
+			wantSections: []onepassword.ItemSection{{ID: "database", Title: "database"}},
+			wantFields: []onepassword.ItemField{
+				newConcealedField(key1, []byte(value1)),
+				sectioned("database", onepassword.ItemField{ID: "database.key1", Title: key1, FieldType: onepassword.ItemFieldTypeConcealed, Value: value1}),
+				sectioned("database", onepassword.ItemField{ID: "database.key2", Title: key2, FieldType: onepassword.ItemFieldTypeConcealed, Value: value2}),
+			},
+		},
+		{
+			name:     "empty section",
+			client:   fake.NewClient().AddVault(myVaultID, myVault),
+			metadata: pushMetadata(`{"section":" "}`),
+			wantErr:  errEmptySection,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &ProviderOnePasswordSdk{client: tt.client.SDKClient()}
+			err := provider.PushSecret(context.Background(), newPushSecret(), testingfake.PushSecretData{
+				RemoteKey: "op://my-vault/new-item",
+				Metadata:  tt.metadata,
+			})
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			if assert.Len(t, tt.client.MockItems[myVaultID], 1) {
+				item := tt.client.MockItems[myVaultID][0]
+				assert.Equal(t, tt.wantSections, item.Sections)
+				assert.Equal(t, tt.wantFields, item.Fields)
+			}
+		})
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient()
+	provider := &ProviderOnePasswordSdk{client: client.SDKClient(), dryRun: true}
+	assert.Equal(t, esv1beta1.SecretStoreReadWrite, provider.Capabilities())
+
+	// create
+	assert.NoError(t, provider.PushSecret(ctx, newPushSecret(), testingfake.PushSecretData{RemoteKey: "op://my-vault/new-item"}))
+	// update
+	assert.NoError(t, provider.PushSecret(ctx, newPushSecret(), testingfake.PushSecretData{RemoteKey: "op://my-vault/my-item", SecretKey: key1, Property: "new-field"}))
+	// delete a field, then the item
+	assert.NoError(t, provider.DeleteSecret(ctx, esv1alpha1.PushSecretRemoteRef{RemoteKey: "op://my-vault/my-item", Property: key1}))
+	assert.NoError(t, provider.DeleteSecret(ctx, esv1alpha1.PushSecretRemoteRef{RemoteKey: "op://my-vault/my-item"}))
+
+	assert.Zero(t, client.Calls[fake.ItemsCreate])
+	assert.Zero(t, client.Calls[fake.ItemsPut])
+	assert.Zero(t, client.Calls[fake.ItemsDelete])
+	assert.Equal(t, newFakeClient().MockItems, client.MockItems)
+}
+
+func TestWriteOnly(t *testing.T) {
+	ctx := context.Background()
+	client := newFakeClient()
+	provider := &ProviderOnePasswordSdk{client: client.SDKClient(), writeOnly: true}
+	assert.Equal(t, esv1beta1.SecretStoreWriteOnly, provider.Capabilities())
+
+	_, err := provider.GetSecret(ctx, esv1beta1.ExternalSecretDataRemoteRef{Key: "op://my-vault/my-item/key1"})
+	assert.EqualError(t, err, errWriteOnlyStore)
+	_, err = provider.GetSecretMap(ctx, esv1beta1.ExternalSecretDataRemoteRef{Key: "op://my-vault/my-item"})
+	assert.EqualError(t, err, errWriteOnlyStore)
+	_, err = provider.GetAllSecrets(ctx, esv1beta1.ExternalSecretFind{Tags: map[string]string{"env": "prod"}})
+	assert.EqualError(t, err, errWriteOnlyStore)
+	ref := esv1beta1.ExternalSecretDataRemoteRef{Key: "op://my-vault/my-item/key1"}
+	assert.EqualError(t, provider.GetSecrets(ctx, []esv1beta1.ExternalSecretDataRemoteRef{ref})[ref].Err, errWriteOnlyStore)
+	assert.Empty(t, client.Calls)
+
+	assert.NoError(t, provider.PushSecret(ctx, newPushSecret(), testingfake.PushSecretData{RemoteKey: "op://my-vault/new-item"}))
+	exists, err := provider.SecretExists(ctx, esv1alpha1.PushSecretRemoteRef{RemoteKey: "op://my-vault/new-item"})
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.NoError(t, provider.DeleteSecret(ctx, esv1alpha1.PushSecretRemoteRef{RemoteKey: "op://my-vault/new-item"}))
+}
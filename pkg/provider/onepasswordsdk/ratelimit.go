@@ -0,0 +1,124 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"context"
+	"sync"
+
+	"github.com/1password/onepassword-sdk-go"
+	"golang.org/x/time/rate"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	"github.com/external-secrets/external-secrets/pkg/cache"
+)
+
+var (
+	// storeLimiters holds the rate limiter of every store, see storeCacheKey.
+	storeLimiters   = cache.Must[*rate.Limiter](storeCacheSize, nil)
+	storeLimitersMu sync.Mutex
+)
+
+// storeLimiter returns the rate limiter of the store for the namespace of the client, creating
+// it when needed. It allows requestsPerSecond calls a second, in bursts of as many.
+func storeLimiter(store esv1beta1.GenericStore, namespace string, requestsPerSecond int) *rate.Limiter {
+	key, version := storeCacheKey(store, namespace)
+
+	storeLimitersMu.Lock()
+	defer storeLimitersMu.Unlock()
+	if limiter, ok := storeLimiters.Get(version, key); ok {
+		return limiter
+	}
+	limiter := rate.NewLimiter(rate.Limit(requestsPerSecond), requestsPerSecond)
+	storeLimiters.Add(version, key, limiter)
+	return limiter
+}
+
+// limitClient wraps every API of the SDK client so that each call to 1Password first waits
+// for limiter, or for its context to be done. A nil limiter leaves the client as is.
+func limitClient(client onepassword.Client, limiter *rate.Limiter) onepassword.Client {
+	if limiter == nil {
+		return client
+	}
+	return onepassword.Client{
+		Secrets: &limitedSecrets{client.Secrets, limiter},
+		Items:   &limitedItems{client.Items, limiter},
+		Vaults:  &limitedVaults{client.Vaults, limiter},
+	}
+}
+
+type limitedSecrets struct {
+	onepassword.SecretsAPI
+	limiter *rate.Limiter
+}
+
+func (s *limitedSecrets) Resolve(ctx context.Context, secretReference string) (string, error) {
+	if err := s.limiter.Wait(ctx); err != nil {
+		return "", err
+	}
+	return s.SecretsAPI.Resolve(ctx, secretReference)
+}
+
+type limitedItems struct {
+	onepassword.ItemsAPI
+	limiter *rate.Limiter
+}
+
+func (i *limitedItems) Create(ctx context.Context, params onepassword.ItemCreateParams) (onepassword.Item, error) {
+	if err := i.limiter.Wait(ctx); err != nil {
+		return onepassword.Item{}, err
+	}
+	return i.ItemsAPI.Create(ctx, params)
+}
+
+func (i *limitedItems) Get(ctx context.Context, vaultID, itemID string) (onepassword.Item, error) {
+	if err := i.limiter.Wait(ctx); err != nil {
+		return onepassword.Item{}, err
+	}
+	return i.ItemsAPI.Get(ctx, vaultID, itemID)
+}
+
+func (i *limitedItems) Put(ctx context.Context, item onepassword.Item) (onepassword.Item, error) {
+	if err := i.limiter.Wait(ctx); err != nil {
+		return onepassword.Item{}, err
+	}
+	return i.ItemsAPI.Put(ctx, item)
+}
+
+func (i *limitedItems) Delete(ctx context.Context, vaultID, itemID string) error {
+	if err := i.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	return i.ItemsAPI.Delete(ctx, vaultID, itemID)
+}
+
+func (i *limitedItems) ListAll(ctx context.Context, vaultID string) (*onepassword.Iterator[onepassword.ItemOverview], error) {
+	if err := i.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return i.ItemsAPI.ListAll(ctx, vaultID)
+}
+
+type limitedVaults struct {
+	onepassword.VaultsAPI
+	limiter *rate.Limiter
+}
+
+func (v *limitedVaults) ListAll(ctx context.Context) (*onepassword.Iterator[onepassword.VaultOverview], error) {
+	if err := v.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return v.VaultsAPI.ListAll(ctx)
+}
@@ -0,0 +1,69 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	"github.com/external-secrets/external-secrets/pkg/provider/onepasswordsdk/fake"
+)
+
+func TestRateLimit(t *testing.T) {
+	ref := esv1beta1.ExternalSecretDataRemoteRef{Key: "op://my-vault/my-item/key1"}
+	client := newFakeClient()
+	// a call an hour, in bursts of 3
+	provider := &ProviderOnePasswordSdk{limiter: rate.NewLimiter(rate.Every(time.Hour), 3)}
+	provider.useClient(ptr.To(client.SDKClient()))
+
+	// a burst goes through, taking a token per call
+	now := time.Now()
+	for range 3 {
+		_, err := provider.GetSecret(context.Background(), ref)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 3, client.Calls[fake.SecretsResolve])
+	assert.InDelta(t, 0, provider.limiter.TokensAt(now), 0.01)
+	assert.InDelta(t, 1, provider.limiter.TokensAt(now.Add(time.Hour)), 0.01)
+
+	// the next call waits for a token, giving up at once when the context of the call would be
+	// done before
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	_, err := provider.GetSecret(ctx, ref)
+	assert.ErrorContains(t, err, "would exceed context deadline")
+	assert.Equal(t, 3, client.Calls[fake.SecretsResolve])
+}
+
+func TestStoreLimiter(t *testing.T) {
+	store := &esv1beta1.SecretStore{
+		TypeMeta:   metav1.TypeMeta{Kind: esv1beta1.SecretStoreKind},
+		ObjectMeta: metav1.ObjectMeta{Name: "limited-store", Namespace: "ns-a", ResourceVersion: "1"},
+	}
+	limiter := storeLimiter(store, "ns-a", 5)
+	assert.Same(t, limiter, storeLimiter(store, "ns-a", 5))
+	assert.Equal(t, rate.Limit(5), limiter.Limit())
+	assert.Equal(t, 5, limiter.Burst())
+
+	store.ResourceVersion = "2"
+	assert.NotSame(t, limiter, storeLimiter(store, "ns-a", 10))
+}
@@ -0,0 +1,178 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	errInvalidSecretReference = "invalid 1Password secret reference %q, expected op://<vault>/<item>[/<section>]/<field>"
+	errExpectedItemRef        = "expected an item-level reference op://<vault>/<item>, got field reference %q"
+	errExpectedFieldRef       = "expected a field reference op://<vault>/<item>[/<section>]/<field> or remoteRef.property, got item reference %q"
+	errInvalidAttribute       = "invalid attribute %q in %q, expected one of: totp, seed"
+	errNoDefaultVault         = "invalid 1Password secret reference %q without a vault, expected op://<vault>/<item>[/<section>]/<field> or spec.provider.onepasswordsdk.defaultVault to be set"
+	errInvalidEncoding        = "invalid 1Password secret reference %q: %w"
+
+	opReferencePrefix = "op://"
+	schemeSep         = "://"
+	opReferenceSep    = "/"
+	opAttributeQuery  = "?attribute="
+
+	// attributeTOTP selects the current code of a one-time password field.
+	attributeTOTP = "totp"
+	// attributeSeed selects the stored otpauth:// URI of a one-time password field.
+	attributeSeed = "seed"
+
+	// itemIDVault stands for whichever vault holds the item in op://item/<item ID>[/<section>]/<field>.
+	itemIDVault = "item"
+)
+
+// itemIDPattern matches the IDs 1Password gives items.
+var itemIDPattern = regexp.MustCompile(`^[a-z0-9]{26}$`)
+
+// secretReference is a parsed op://<vault>/<item>[/<section>]/<field>[?attribute=<attribute>] reference.
+// Field and section are empty for item-level references.
+type secretReference struct {
+	vault     string
+	item      string
+	section   string
+	field     string
+	attribute string
+}
+
+// parseSecretReference validates the scheme and segment count of a secret reference
+// and splits it into its components. A reference without the op:// scheme,
+// <item>[/<section>]/<field>, is in defaultVault. op://<item>/<field> cannot be abbreviated
+// that way, as it already means the item <field> in the vault <item>.
+// Segments are percent-decoded, so that names with a slash or other special characters can be
+// written as in op://My%20Vault/a%2Fb/field. Whitespace around the reference, such as the
+// trailing newline of a reference copied from a file, is ignored, spaces within it are not.
+func parseSecretReference(key, defaultVault string) (secretReference, error) {
+	key = strings.TrimSpace(key)
+	path, attribute, err := splitAttribute(key)
+	if err != nil {
+		return secretReference{}, err
+	}
+	var parts []string
+	switch {
+	case strings.HasPrefix(path, opReferencePrefix):
+		parts = strings.Split(strings.TrimPrefix(path, opReferencePrefix), opReferenceSep)
+	case strings.Contains(path, schemeSep):
+		return secretReference{}, fmt.Errorf(errInvalidSecretReference, key)
+	case defaultVault == "":
+		return secretReference{}, fmt.Errorf(errNoDefaultVault, key)
+	default:
+		parts = strings.Split(path, opReferenceSep)
+	}
+	if slices.Contains(parts, "") {
+		return secretReference{}, fmt.Errorf(errInvalidSecretReference, key)
+	}
+	for i, part := range parts {
+		if parts[i], err = url.PathUnescape(part); err != nil {
+			return secretReference{}, fmt.Errorf(errInvalidEncoding, key, err)
+		}
+	}
+	if !strings.HasPrefix(path, opReferencePrefix) {
+		// the default vault is a name as is, not part of the reference
+		parts = append([]string{defaultVault}, parts...)
+	}
+	if len(parts) < 2 || len(parts) > 4 {
+		return secretReference{}, fmt.Errorf(errInvalidSecretReference, key)
+	}
+
+	ref := secretReference{vault: parts[0], item: parts[1], attribute: attribute}
+	switch len(parts) {
+	case 2:
+		if attribute != "" {
+			return secretReference{}, fmt.Errorf(errInvalidSecretReference, key)
+		}
+	case 3:
+		ref.field = parts[2]
+	case 4:
+		ref.section = parts[2]
+		ref.field = parts[3]
+	}
+	return ref, nil
+}
+
+// String returns the reference in full, as op://<vault>/<item>[/<section>][/<field>][?attribute=<attribute>].
+func (ref secretReference) String() string {
+	parts := []string{ref.vault, ref.item}
+	if ref.section != "" {
+		parts = append(parts, ref.section)
+	}
+	if ref.field != "" {
+		parts = append(parts, ref.field)
+	}
+	s := opReferencePrefix + strings.Join(parts, opReferenceSep)
+	if ref.attribute != "" {
+		s += opAttributeQuery + ref.attribute
+	}
+	return s
+}
+
+// byItemID reports whether the reference is op://item/<item ID>[/<section>][/<field>], which
+// looks the item up by ID in every accessible vault, unless a vault is actually named item.
+func (ref secretReference) byItemID() bool {
+	return isItemIDReference(ref.vault, ref.item)
+}
+
+func isItemIDReference(vault, item string) bool {
+	return vault == itemIDVault && itemIDPattern.MatchString(item)
+}
+
+// resolvable reports whether the SDK can resolve the reference as is. It takes names verbatim,
+// so a name with a slash or a question mark only matches through a lookup by title.
+func (ref secretReference) resolvable() bool {
+	if ref.byItemID() {
+		return false
+	}
+	for _, part := range []string{ref.vault, ref.item, ref.section, ref.field} {
+		if strings.ContainsAny(part, opReferenceSep+"?") {
+			return false
+		}
+	}
+	return true
+}
+
+// splitAttribute splits the optional ?attribute=<attribute> suffix off a secret reference or
+// a property, as in the secret reference syntax of 1Password.
+func splitAttribute(s string) (string, string, error) {
+	name, attribute, found := strings.Cut(s, opAttributeQuery)
+	if !found {
+		return s, "", nil
+	}
+	if attribute != attributeTOTP && attribute != attributeSeed {
+		return "", "", fmt.Errorf(errInvalidAttribute, attribute, s)
+	}
+	return name, attribute, nil
+}
+
+// parseItemReference parses a reference that must point at an item rather than a field.
+func parseItemReference(key, defaultVault string) (secretReference, error) {
+	ref, err := parseSecretReference(key, defaultVault)
+	if err != nil {
+		return secretReference{}, err
+	}
+	if ref.field != "" {
+		return secretReference{}, fmt.Errorf(errExpectedItemRef, key)
+	}
+	return ref, nil
+}
@@ -0,0 +1,202 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSecretReference(t *testing.T) {
+	tests := []struct {
+		name         string
+		key          string
+		defaultVault string
+		want         secretReference
+		wantErr      string
+	}{
+		{
+			name: "item",
+			key:  "op://vault/item",
+			want: secretReference{vault: "vault", item: "item"},
+		},
+		{
+			name: "field",
+			key:  "op://vault/item/field",
+			want: secretReference{vault: "vault", item: "item", field: "field"},
+		},
+		{
+			name: "section qualified field",
+			key:  "op://vault/item/section/field",
+			want: secretReference{vault: "vault", item: "item", section: "section", field: "field"},
+		},
+		{
+			name: "field with attribute",
+			key:  "op://vault/item/one-time password?attribute=totp",
+			want: secretReference{vault: "vault", item: "item", field: "one-time password", attribute: attributeTOTP},
+		},
+		{
+			name:    "unknown attribute",
+			key:     "op://vault/item/field?attribute=type",
+			wantErr: `invalid attribute "type"`,
+		},
+		{
+			name:    "item with attribute",
+			key:     "op://vault/item?attribute=totp",
+			wantErr: "invalid 1Password secret reference",
+		},
+		{
+			name:    "missing scheme",
+			key:     "vault/item/field",
+			wantErr: "expected op://<vault>/<item>[/<section>]/<field>",
+		},
+		{
+			name:         "field in default vault",
+			key:          "item/field",
+			defaultVault: "default",
+			want:         secretReference{vault: "default", item: "item", field: "field"},
+		},
+		{
+			name:         "section qualified field in default vault",
+			key:          "item/section/field?attribute=seed",
+			defaultVault: "default",
+			want:         secretReference{vault: "default", item: "item", section: "section", field: "field", attribute: attributeSeed},
+		},
+		{
+			name:         "item in default vault",
+			key:          "item",
+			defaultVault: "default",
+			want:         secretReference{vault: "default", item: "item"},
+		},
+		{
+			name:         "full reference overrides the default vault",
+			key:          "op://vault/item/field",
+			defaultVault: "default",
+			want:         secretReference{vault: "vault", item: "item", field: "field"},
+		},
+		{
+			name:    "missing scheme without default vault",
+			key:     "item/field",
+			wantErr: "without a vault",
+		},
+		{
+			name:         "wrong scheme with default vault",
+			key:          "https://vault/item/field",
+			defaultVault: "default",
+			wantErr:      "invalid 1Password secret reference",
+		},
+		{
+			name:    "wrong scheme",
+			key:     "https://vault/item/field",
+			wantErr: "invalid 1Password secret reference",
+		},
+		{
+			name:    "vault only",
+			key:     "op://vault",
+			wantErr: "invalid 1Password secret reference",
+		},
+		{
+			name:    "too many segments",
+			key:     "op://vault/item/section/field/extra",
+			wantErr: "invalid 1Password secret reference",
+		},
+		{
+			name:    "empty segment",
+			key:     "op://vault//field",
+			wantErr: "invalid 1Password secret reference",
+		},
+		{
+			name: "encoded space",
+			key:  "op://My%20Vault/my%20item/field",
+			want: secretReference{vault: "My Vault", item: "my item", field: "field"},
+		},
+		{
+			name: "encoded slash",
+			key:  "op://vault/ci%2Fcd/section/api%2Fkey",
+			want: secretReference{vault: "vault", item: "ci/cd", section: "section", field: "api/key"},
+		},
+		{
+			name: "encoded unicode",
+			key:  "op://Coffre%20%C3%A9quipe/cl%C3%A9/mot%20de%20passe",
+			want: secretReference{vault: "Coffre équipe", item: "clé", field: "mot de passe"},
+		},
+		{
+			name:         "encoded slash in default vault",
+			key:          "a%2Fb/field",
+			defaultVault: "100%",
+			want:         secretReference{vault: "100%", item: "a/b", field: "field"},
+		},
+		{
+			name: "trailing newline",
+			key:  "op://vault/item/field\n",
+			want: secretReference{vault: "vault", item: "item", field: "field"},
+		},
+		{
+			name: "surrounding whitespace",
+			key:  " \top://vault/item/field \r\n",
+			want: secretReference{vault: "vault", item: "item", field: "field"},
+		},
+		{
+			name: "embedded spaces",
+			key:  " op://My Vault/My Item/my field\n",
+			want: secretReference{vault: "My Vault", item: "My Item", field: "my field"},
+		},
+		{
+			name:         "trailing newline in default vault",
+			key:          "item/field\n",
+			defaultVault: "vault",
+			want:         secretReference{vault: "vault", item: "item", field: "field"},
+		},
+		{
+			name:    "invalid encoding",
+			key:     "op://vault/100%/field",
+			wantErr: `invalid URL escape "%"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSecretReference(tt.key, tt.defaultVault)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseItemReference(t *testing.T) {
+	_, err := parseItemReference("op://vault/item/section/field", "")
+	assert.ErrorContains(t, err, "expected an item-level reference")
+
+	ref, err := parseItemReference("op://vault/item", "")
+	assert.NoError(t, err)
+	assert.Equal(t, secretReference{vault: "vault", item: "item"}, ref)
+}
+
+func TestSecretReferenceString(t *testing.T) {
+	for _, key := range []string{
+		"op://vault/item",
+		"op://vault/item/field",
+		"op://vault/item/section/field",
+		"op://vault/item/field?attribute=totp",
+	} {
+		ref, err := parseSecretReference(key, "")
+		assert.NoError(t, err)
+		assert.Equal(t, key, ref.String())
+	}
+}
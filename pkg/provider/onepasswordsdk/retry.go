@@ -0,0 +1,155 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+)
+
+const (
+	errInvalidRetryInterval = "invalid spec.retrySettings.retryInterval: %w"
+
+	defaultMaxRetries    = 3
+	defaultRetryInterval = time.Second
+	maxRetryInterval     = 30 * time.Second
+)
+
+// transientErrors are matched against the error messages of the SDK, like in mapError.
+var transientErrors = []string{
+	"too many requests",
+	"rate limit",
+	"internal server error",
+	"bad gateway",
+	"service unavailable",
+	"gateway timeout",
+	"connection reset",
+	"connection refused",
+	"i/o timeout",
+	"unexpected eof",
+}
+
+// connectionErrors are the transientErrors, and a few more, about the connection to 1Password
+// dropping rather than 1Password failing to serve a request.
+var connectionErrors = []string{
+	"connection reset",
+	"connection refused",
+	"broken pipe",
+	"use of closed network connection",
+	"unexpected eof",
+}
+
+// retrier retries calls to 1Password failing with a transient error, with exponential backoff
+// and jitter. A nil *retrier makes a single attempt.
+type retrier struct {
+	maxRetries uint64
+	interval   time.Duration
+}
+
+// newRetrier configures retries from spec.retrySettings. As with the other providers,
+// nothing is retried unless retrySettings is set.
+func newRetrier(settings *esv1beta1.SecretStoreRetrySettings) (*retrier, error) {
+	if settings == nil {
+		return nil, nil
+	}
+	r := &retrier{
+		maxRetries: defaultMaxRetries,
+		interval:   defaultRetryInterval,
+	}
+	if settings.MaxRetries != nil && *settings.MaxRetries >= 0 {
+		r.maxRetries = uint64(*settings.MaxRetries)
+	}
+	if settings.RetryInterval != nil {
+		interval, err := time.ParseDuration(*settings.RetryInterval)
+		if err != nil {
+			return nil, fmt.Errorf(errInvalidRetryInterval, err)
+		}
+		r.interval = interval
+	}
+	return r, nil
+}
+
+// retry calls fn until it succeeds, fails with an error that is not transient, the retries are
+// exhausted or ctx is done.
+func retry[T any](ctx context.Context, r *retrier, fn func() (T, error)) (T, error) {
+	if r == nil {
+		return fn()
+	}
+	b := backoff.NewExponentialBackOff(
+		backoff.WithInitialInterval(r.interval),
+		backoff.WithMaxInterval(maxRetryInterval),
+		backoff.WithMaxElapsedTime(0),
+	)
+	return backoff.RetryWithData(func() (T, error) {
+		value, err := fn()
+		if err != nil && !isTransient(err) {
+			return value, backoff.Permanent(err)
+		}
+		return value, err
+	}, backoff.WithContext(backoff.WithMaxRetries(b, r.maxRetries), ctx))
+}
+
+// isUnavailable reports whether err means 1Password could not be reached or could not serve the
+// request for now, as opposed to rejecting it.
+func isUnavailable(err error) bool {
+	return isTransient(err) || errors.Is(err, context.DeadlineExceeded) || strings.Contains(strings.ToLower(err.Error()), "no such host")
+}
+
+// isConnectionError reports whether err is the connection to 1Password dropping, which
+// rebuilding the client may recover from, as opposed to 1Password rejecting or failing a request.
+func isConnectionError(err error) bool {
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, connection := range connectionErrors {
+		if strings.Contains(msg, connection) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTransient reports whether err is worth retrying: rate limiting, server side and network errors.
+// Anything else, such as missing items or permissions, fails right away.
+func isTransient(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, transient := range transientErrors {
+		if strings.Contains(msg, transient) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/utils/ptr"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	"github.com/external-secrets/external-secrets/pkg/provider/onepasswordsdk/fake"
+)
+
+func TestRetry(t *testing.T) {
+	errRateLimited := errors.New("Too Many Requests: rate limit exceeded")
+	testRetrier := &retrier{maxRetries: 3, interval: time.Millisecond}
+	tests := []struct {
+		name      string
+		retrier   *retrier
+		client    *fake.Client
+		wantCalls int
+		wantErr   string
+	}{
+		{
+			name:      "transient errors are retried",
+			retrier:   testRetrier,
+			client:    newFakeClient().WithErrorTimes(fake.SecretsResolve, errRateLimited, 2),
+			wantCalls: 3,
+		},
+		{
+			name:      "retries are exhausted",
+			retrier:   testRetrier,
+			client:    newFakeClient().WithErrorTimes(fake.SecretsResolve, errRateLimited, 5),
+			wantCalls: 4,
+			wantErr:   "rate limit exceeded",
+		},
+		{
+			name:      "not found fails fast",
+			retrier:   testRetrier,
+			client:    newFakeClient().WithError(fake.SecretsResolve, fake.ErrNotFound).WithError(fake.ItemsGet, fake.ErrNotFound),
+			wantCalls: 1,
+			wantErr:   fake.ErrNotFound.Error(),
+		},
+		{
+			name:      "nothing is retried without retrySettings",
+			client:    newFakeClient().WithErrorTimes(fake.SecretsResolve, errRateLimited, 1),
+			wantCalls: 1,
+			wantErr:   "rate limit exceeded",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &ProviderOnePasswordSdk{client: tt.client.SDKClient(), retrier: tt.retrier}
+			got, err := provider.GetSecret(context.Background(), esv1beta1.ExternalSecretDataRemoteRef{Key: "op://my-vault/my-item/key1"})
+			assert.Equal(t, tt.wantCalls, tt.client.Calls[fake.SecretsResolve])
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, []byte(value1), got)
+		})
+	}
+}
+
+func TestRetryGetSecretMapAndValidate(t *testing.T) {
+	errUnavailable := errors.New("503 Service Unavailable")
+	client := newFakeClient().
+		WithErrorTimes(fake.ItemsListAll, errUnavailable, 1).
+		WithErrorTimes(fake.VaultsListAll, errUnavailable, 2)
+	provider := &ProviderOnePasswordSdk{
+		client:  client.SDKClient(),
+		retrier: &retrier{maxRetries: 3, interval: time.Millisecond},
+	}
+
+	result, err := provider.Validate()
+	assert.NoError(t, err)
+	assert.Equal(t, esv1beta1.ValidationResultReady, result)
+
+	_, err = provider.GetSecretMap(context.Background(), esv1beta1.ExternalSecretDataRemoteRef{Key: "op://my-vault/my-item"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, client.Calls[fake.ItemsListAll])
+}
+
+func TestNewRetrier(t *testing.T) {
+	r, err := newRetrier(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, r)
+
+	r, err = newRetrier(&esv1beta1.SecretStoreRetrySettings{})
+	assert.NoError(t, err)
+	assert.Equal(t, &retrier{maxRetries: defaultMaxRetries, interval: defaultRetryInterval}, r)
+
+	r, err = newRetrier(&esv1beta1.SecretStoreRetrySettings{MaxRetries: ptr.To[int32](5), RetryInterval: ptr.To("10ms")})
+	assert.NoError(t, err)
+	assert.Equal(t, &retrier{maxRetries: 5, interval: 10 * time.Millisecond}, r)
+
+	_, err = newRetrier(&esv1beta1.SecretStoreRetrySettings{RetryInterval: ptr.To("soon")})
+	assert.ErrorContains(t, err, "invalid spec.retrySettings.retryInterval")
+}
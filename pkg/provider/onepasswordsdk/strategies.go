@@ -0,0 +1,109 @@
+/*
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package onepasswordsdk
+
+import (
+	"fmt"
+	"slices"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	"github.com/external-secrets/external-secrets/pkg/utils"
+)
+
+const (
+	errDefaultDecoding  = "could not decode 1Password secret with spec.provider.onepasswordsdk.defaultDecodingStrategy %s: %w"
+	errDefaultDecodeKey = "could not decode key %q of 1Password Item %q with spec.provider.onepasswordsdk.defaultDecodingStrategy %s: %w"
+	errDefaultConvert   = "could not convert the keys of 1Password Item %q with spec.provider.onepasswordsdk.defaultConversionStrategy %s: %w"
+)
+
+// conversionStrategies are the strategies defaultConversionStrategy accepts.
+var conversionStrategies = []esv1beta1.ExternalSecretConversionStrategy{
+	esv1beta1.ExternalSecretConversionDefault,
+	esv1beta1.ExternalSecretConversionUnicode,
+}
+
+// conversionStrategy returns the strategy the keys of a secret map read with the given
+// conversionStrategy end up converted with, and whether it is the default of the store.
+// The controller applies the conversionStrategy and decodingStrategy of a remoteRef to what the
+// provider returns, and the CRD defaults them to Default and None: a remoteRef leaving them unset
+// cannot be told apart from one setting them. The defaults of the store therefore apply to the
+// remoteRefs with those values, and the provider converts and decodes the secrets itself, leaving
+// the controller nothing more to do.
+func (provider *ProviderOnePasswordSdk) conversionStrategy(strategy esv1beta1.ExternalSecretConversionStrategy) (esv1beta1.ExternalSecretConversionStrategy, bool) {
+	if provider.defaultConversion == "" || provider.defaultConversion == esv1beta1.ExternalSecretConversionDefault {
+		return strategy, false
+	}
+	if strategy != "" && strategy != esv1beta1.ExternalSecretConversionDefault {
+		return strategy, false
+	}
+	return provider.defaultConversion, true
+}
+
+// decodingStrategy returns the strategy a secret read with the given decodingStrategy ends up
+// decoded with, and whether it is the default of the store.
+func (provider *ProviderOnePasswordSdk) decodingStrategy(strategy esv1beta1.ExternalSecretDecodingStrategy) (esv1beta1.ExternalSecretDecodingStrategy, bool) {
+	if provider.defaultDecoding == "" || provider.defaultDecoding == esv1beta1.ExternalSecretDecodeNone {
+		return strategy, false
+	}
+	if strategy != "" && strategy != esv1beta1.ExternalSecretDecodeNone {
+		return strategy, false
+	}
+	return provider.defaultDecoding, true
+}
+
+// decodeDefault decodes the value GetSecret returns with the default decoding strategy of the
+// store, when it applies to ref.
+func (provider *ProviderOnePasswordSdk) decodeDefault(ref esv1beta1.ExternalSecretDataRemoteRef, value []byte) ([]byte, error) {
+	strategy, ok := provider.decodingStrategy(ref.DecodingStrategy)
+	if !ok {
+		return value, nil
+	}
+	decoded, err := utils.Decode(strategy, value)
+	if err != nil {
+		return nil, fmt.Errorf(errDefaultDecoding, strategy, err)
+	}
+	return decoded, nil
+}
+
+// applyDefaults decodes the values of the secret map GetSecretMap returns for the item, other
+// than those fieldDecodingStrategies already decoded, then converts its keys, with the default
+// strategies of the store, when they apply to ref.
+func (provider *ProviderOnePasswordSdk) applyDefaults(ref esv1beta1.ExternalSecretDataRemoteRef, title string, secretData map[string][]byte) (map[string][]byte, error) {
+	if strategy, ok := provider.decodingStrategy(ref.DecodingStrategy); ok {
+		for key, value := range secretData {
+			if _, own := provider.fieldDecoding[key]; own {
+				continue
+			}
+			decoded, err := utils.Decode(strategy, value)
+			if err != nil {
+				return nil, fmt.Errorf(errDefaultDecodeKey, key, title, strategy, err)
+			}
+			secretData[key] = decoded
+		}
+	}
+	if strategy, ok := provider.conversionStrategy(ref.ConversionStrategy); ok {
+		converted, err := utils.ConvertKeys(strategy, secretData)
+		if err != nil {
+			return nil, fmt.Errorf(errDefaultConvert, title, strategy, err)
+		}
+		return converted, nil
+	}
+	return secretData, nil
+}
+
+// validConversionStrategy reports whether strategy is empty or one defaultConversionStrategy accepts.
+func validConversionStrategy(strategy esv1beta1.ExternalSecretConversionStrategy) bool {
+	return strategy == "" || slices.Contains(conversionStrategies, strategy)
+}